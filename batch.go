@@ -0,0 +1,224 @@
+package gntagger
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gnames/bayes"
+	"github.com/gnames/gnfinder/lang"
+	"github.com/gnames/gnfinder/nlp"
+	"github.com/gnames/gnfinder/output"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// IndexFile is the name of the summary of texts processed in batch mode.
+// It is saved in the processed directory.
+const IndexFile = "gntagger_index.tsv"
+
+// Statuses of texts processed in batch mode.
+const (
+	// BatchCreated means a new session was created for a text.
+	BatchCreated = "created"
+	// BatchUpdated means the text changed, data of its old session were
+	// backed up, and a new session was created.
+	BatchUpdated = "updated"
+	// BatchSkipped means a session of the same text already exists.
+	BatchSkipped = "skipped"
+	// BatchFailed means the text could not be processed.
+	BatchFailed = "failed"
+)
+
+// IndexEntry describes a text processed in batch mode.
+type IndexEntry struct {
+	// File is the path to the text.
+	File string
+	// Checksum of the text.
+	Checksum string
+	// Names is the number of names found in the text.
+	Names int
+	// Language is the code of the language of the text.
+	Language string
+	// Status is one of BatchCreated, BatchUpdated, BatchSkipped,
+	// BatchFailed.
+	Status string
+	// Err is the reason of a failure.
+	Err error
+}
+
+// BatchFiles returns paths to files with the given extension in a directory
+// and its subdirectories. Directories of sessions are ignored.
+func BatchFiles(dir string, ext string) ([]string, error) {
+	var res []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo,
+		err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && strings.HasSuffix(path, "_gntagger") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() && filepath.Ext(path) == ext {
+			res = append(res, path)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// Batch creates curation sessions for texts concurrently. Texts are
+// wrapped to the given width, and processed by the given number of
+// workers. Texts that already have sessions are skipped. Entries of the
+// result are in the order of paths. Bayes weights of the GnTagger are
+// loaded once if they are not set, and every worker gets its own copy.
+func Batch(paths []string, workers int, width int, version string,
+	gnt *GnTagger) []IndexEntry {
+	if workers < 1 {
+		workers = 1
+	}
+	weights := gnt.BayesWeights
+	if weights == nil {
+		weights = nlp.BayesWeights()
+	}
+	res := make([]IndexEntry, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		// Bayes classifiers keep data of the current prediction, so every
+		// worker needs its own copy of the weights.
+		g := *gnt
+		g.BayesWeights = weights
+		if i > 0 {
+			g.BayesWeights = copyWeights(weights)
+		}
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				res[j] = batchText(paths[j], width, version, &g)
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return res
+}
+
+// copyWeights returns a copy of Bayes weights. Copies are restored from
+// dumps, so they must not be made while weights are loaded elsewhere.
+func copyWeights(
+	w map[lang.Language]*bayes.NaiveBayes) map[lang.Language]*bayes.NaiveBayes {
+	res := make(map[lang.Language]*bayes.NaiveBayes, len(w))
+	for l, nb := range w {
+		c := bayes.NewNaiveBayes()
+		c.Restore(nb.Dump())
+		res[l] = c
+	}
+	return res
+}
+
+// batchText creates a session for a text, if it does not exist yet.
+func batchText(path string, width int, version string,
+	gnt *GnTagger) (entry IndexEntry) {
+	entry = IndexEntry{File: path, Status: BatchFailed}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+	t := NewText(data, path, version)
+	entry.Checksum = t.Checksum
+
+	old, err := sessionMeta(t)
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+	status := BatchCreated
+	if old != nil {
+		if old.Checksum == t.Checksum {
+			entry.Language = old.Language
+			entry.Names, entry.Err = namesNumber(t)
+			if entry.Err == nil {
+				entry.Status = BatchSkipped
+			}
+			return entry
+		}
+		status = BatchUpdated
+	}
+
+	// functions that create sessions panic on errors
+	defer func() {
+		if r := recover(); r != nil {
+			entry.Status = BatchFailed
+			entry.Err = fmt.Errorf("%v", r)
+		}
+	}()
+	if old != nil {
+		moveOldFiles(t, old.Timestamp)
+	}
+	names := PrepareFilesAndText(t, width, gnt)
+	entry.Names = len(names.Data.Names)
+	entry.Language = t.Language
+	entry.Status = status
+	return entry
+}
+
+// sessionMeta returns meta-information of an existing session of the text,
+// or nil if there is no session.
+func sessionMeta(t *Text) (*TextMeta, error) {
+	for ft := range t.Files {
+		if _, err := os.Stat(t.FilePath(ft)); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	b, err := ioutil.ReadFile(t.FilePath(MetaFile))
+	if err != nil {
+		return nil, err
+	}
+	var res TextMeta
+	if err = jsoniter.Unmarshal(b, &res); err != nil {
+		return nil, fmt.Errorf("%s: %s", t.FilePath(MetaFile), err)
+	}
+	return &res, nil
+}
+
+// namesNumber reads the number of names from the names file of a session.
+func namesNumber(t *Text) (int, error) {
+	b, err := ioutil.ReadFile(t.FilePath(NamesFile))
+	if err != nil {
+		return 0, err
+	}
+	var o struct {
+		Names []output.Name `json:"names"`
+	}
+	if err = jsoniter.Unmarshal(b, &o); err != nil {
+		return 0, fmt.Errorf("%s: %s", t.FilePath(NamesFile), err)
+	}
+	return len(o.Names), nil
+}
+
+// WriteIndex saves entries of a batch as a tab-separated file.
+func WriteIndex(path string, entries []IndexEntry) error {
+	var b bytes.Buffer
+	b.WriteString("File\tChecksum\tNames\tLanguage\tStatus\tError\n")
+	for _, v := range entries {
+		var msg string
+		if v.Err != nil {
+			msg = strings.Join(strings.Fields(v.Err.Error()), " ")
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%d\t%s\t%s\t%s\n", v.File, v.Checksum,
+			v.Names, v.Language, v.Status, msg)
+	}
+	return ioutil.WriteFile(path, b.Bytes(), 0644)
+}
@@ -0,0 +1,107 @@
+package gntagger_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gnames/gnfinder/dict"
+	"github.com/gnames/gnfinder/nlp"
+	. "github.com/gnames/gntagger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Batch", func() {
+	It("creates sessions for texts of a directory", func() {
+		dir, err := ioutil.TempDir("", "gntagger_batch")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		Expect(os.MkdirAll(filepath.Join(dir, "vol2"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "old.txt_gntagger"),
+			0755)).To(Succeed())
+		files := map[string][]byte{
+			"vol1.txt":                   dataShort,
+			"vol2/vol2.txt":              append(dataShort, "\nVenus.\n"...),
+			"notes.md":                   dataShort,
+			"old.txt_gntagger/input.txt": dataShort,
+		}
+		for k, v := range files {
+			Expect(ioutil.WriteFile(filepath.Join(dir, k), v,
+				0644)).To(Succeed())
+		}
+
+		paths, err := BatchFiles(dir, ".txt")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(paths).To(Equal([]string{filepath.Join(dir, "vol1.txt"),
+			filepath.Join(dir, "vol2", "vol2.txt")}))
+
+		gnt := NewGnTagger()
+		gnt.Dict = dict.LoadDictionary()
+		gnt.BayesWeights = nlp.BayesWeights()
+		entries := Batch(paths, 2, 64, "abcd", gnt)
+		Expect(entries).To(HaveLen(2))
+		for _, v := range entries {
+			Expect(v.Err).ToNot(HaveOccurred())
+			Expect(v.Status).To(Equal(BatchCreated))
+			Expect(v.Language).To(Equal("eng"))
+		}
+		Expect(entries[0].File).To(Equal(paths[0]))
+		Expect(entries[0].Names).To(Equal(7))
+		Expect(entries[1].Names).To(Equal(7))
+		t, n, err := OpenSession(paths[1])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t.Checksum).To(Equal(entries[1].Checksum))
+		Expect(n.Data.Names).To(HaveLen(7))
+
+		Expect(ioutil.WriteFile(paths[1], dataShort, 0644)).To(Succeed())
+		entries = Batch(paths, 2, 64, "abcd", gnt)
+		Expect(entries[0].Status).To(Equal(BatchSkipped))
+		Expect(entries[0].Names).To(Equal(7))
+		Expect(entries[1].Status).To(Equal(BatchUpdated))
+		Expect(entries[1].Names).To(Equal(7))
+
+		Expect(ioutil.WriteFile(filepath.Join(dir, "vol1.txt_gntagger",
+			"meta.json"), []byte("{"), 0644)).To(Succeed())
+		entries = Batch(paths, 1, 64, "abcd", gnt)
+		Expect(entries[0].Status).To(Equal(BatchFailed))
+		Expect(entries[0].Err).To(HaveOccurred())
+		Expect(entries[1].Status).To(Equal(BatchSkipped))
+
+		index := filepath.Join(dir, IndexFile)
+		Expect(WriteIndex(index, entries)).To(Succeed())
+		b, err := ioutil.ReadFile(index)
+		Expect(err).ToNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		Expect(lines).To(HaveLen(3))
+		Expect(lines[0]).To(Equal("File\tChecksum\tNames\tLanguage\tStatus\tError"))
+		Expect(lines[2]).To(HavePrefix(paths[1] + "\t" + entries[1].Checksum +
+			"\t7\teng\tskipped"))
+	})
+
+	// Run with -race: workers must not load Bayes weights concurrently.
+	It("shares name-finding data between workers", func() {
+		dir, err := ioutil.TempDir("", "gntagger_batch")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		var paths []string
+		for i := 0; i < 4; i++ {
+			path := filepath.Join(dir, fmt.Sprintf("vol%d.txt", i))
+			// long texts make workers find names at the same time
+			Expect(ioutil.WriteFile(path, bytes.Repeat(dataShort, 20),
+				0644)).To(Succeed())
+			paths = append(paths, path)
+		}
+		gnt := NewGnTagger()
+		gnt.Dict = dict.LoadDictionary()
+		gnt.BayesWeights = nlp.BayesWeights()
+		for _, v := range Batch(paths, 4, 64, "abcd", gnt) {
+			Expect(v.Err).ToNot(HaveOccurred())
+			Expect(v.Names).To(Equal(140))
+		}
+	})
+})
@@ -28,10 +28,45 @@ allow to annotate the "current name" differently:
 
 * 's':  marks a name as "Species"
 
+* 'x':   switches expansion of an abbreviated name like "O. vulgaris"
+
+* 'p':   switches propagation of decisions to following names
+
+* 'o':   switches between document and informative order of review
+
+* 'm':   switches review of distinct name-strings instead of occurrences
+
+* Up/Down: shows other occurrences of a name in the unique-name mode
+
+* 'e':   marks an occurrence as an exception in the unique-name mode
+
+* '/':   searches name-strings and text with a regular expression
+
+* 'n', 'N': go to the next/previous match of the search
+
+* ':':   jumps to a name by its number
+
+* 'f':   filters names by annotation, type and log-odds
+
+* 'l':   shows likelihoods from which the score of a name is calculated
+
+* 'v':   switches statistics between name-strings and occurrences of names
+
+* '[', ']', PgUp, PgDn: scroll the text without changing the current name
+
+* 'c':   returns the text to the current name
+
+* '?':   shows help with all keys, modes and annotations
+
+* Ctrl-P: opens the command palette to run actions by name
+
 * Ctrl-C: saves curation and exits application
 
 * Ctrl-S: saves curations made so far
 
+Keys can be changed in the "keys" section of ~/.gntagger.yaml configuration
+file.
+
 The program autosaves results of curation. If the program crashes, or exited
 the user can continue curation at the last point instead of starting from
 scratch.
@@ -0,0 +1,162 @@
+package gntagger
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gnames/gnfinder/output"
+	"github.com/gnames/gntagger/annotation"
+)
+
+var abbrRe = regexp.MustCompile(`^([A-Z][a-z]{0,2})\.\s*(\S.*)$`)
+
+// higherRankEnds are endings of uninomials that cannot be genera.
+var higherRankEnds = []string{"idae", "inae", "oidea", "aceae", "oideae", "ales"}
+
+// Expansion is a proposed full form of an abbreviated name, for example
+// "Octopus vulgaris" for "O. vulgaris".
+type Expansion struct {
+	// Name is the expanded name-string. It is empty if a curator decided that
+	// the abbreviation should not be expanded.
+	Name string `json:"name"`
+	// Curated is true when the expansion was set by a curator. Such expansions
+	// are never recalculated.
+	Curated bool `json:"curated,omitempty"`
+}
+
+// IsAbbreviated returns true if the name starts with an abbreviated genus,
+// like "O. vulgaris".
+func IsAbbreviated(n *output.Name) bool {
+	return abbrRe.MatchString(n.Name)
+}
+
+// ExpandAbbreviations finds the nearest preceding full genus with the same
+// initial for every abbreviated name and records the expanded name. Expansions
+// set by a curator stay intact.
+func (n *Names) ExpandAbbreviations() {
+	if n.Expansions == nil {
+		n.Expansions = make(map[int]*Expansion)
+	}
+	var genera []string
+	for i := range n.Data.Names {
+		name := &n.Data.Names[i]
+		if IsAbbreviated(name) {
+			if e, ok := n.Expansions[i]; ok && e.Curated {
+				continue
+			}
+			delete(n.Expansions, i)
+			prefix, rest := splitAbbr(name.Name)
+			for j := len(genera) - 1; j >= 0; j-- {
+				if strings.HasPrefix(genera[j], prefix) {
+					n.Expansions[i] = &Expansion{Name: genera[j] + " " + rest}
+					break
+				}
+			}
+			continue
+		}
+		if g := genus(name); g != "" {
+			genera = append(genera, g)
+		}
+	}
+}
+
+// ExpansionCandidates returns distinct genera that precede a name with the
+// given index and match its abbreviation. The nearest genus goes first.
+func (n *Names) ExpansionCandidates(i int) []string {
+	var res []string
+	name := &n.Data.Names[i]
+	if !IsAbbreviated(name) {
+		return res
+	}
+	prefix, _ := splitAbbr(name.Name)
+	seen := make(map[string]struct{})
+	for j := i - 1; j >= 0; j-- {
+		g := genus(&n.Data.Names[j])
+		if g == "" || !strings.HasPrefix(g, prefix) {
+			continue
+		}
+		if _, ok := seen[g]; ok {
+			continue
+		}
+		seen[g] = struct{}{}
+		res = append(res, g)
+	}
+	return res
+}
+
+// CycleExpansion overrides the expansion of the current name with the next
+// candidate genus. After the last candidate the abbreviation stays
+// unexpanded, and the cycle starts again. Verified names are matched again
+// with their new expansion.
+func (n *Names) CycleExpansion() {
+	i := n.Data.Meta.CurrentName
+	name := n.GetCurrentName()
+	cands := n.ExpansionCandidates(i)
+	if len(cands) == 0 {
+		return
+	}
+	if n.Expansions == nil {
+		n.Expansions = make(map[int]*Expansion)
+	}
+	_, rest := splitAbbr(name.Name)
+	next := 0
+	if e, ok := n.Expansions[i]; ok && e.Name != "" {
+		next = len(cands)
+		for j, g := range cands {
+			if e.Name == g+" "+rest {
+				next = j + 1
+				break
+			}
+		}
+	}
+
+	exp := &Expansion{Curated: true}
+	if next < len(cands) {
+		exp.Name = cands[next] + " " + rest
+	}
+	n.Expansions[i] = exp
+	if n.Matches != nil {
+		n.match(i)
+	}
+}
+
+// ExpandedName returns the expanded form of a name with the given index, or
+// an empty string if there is no expansion.
+func (n *Names) ExpandedName(i int) string {
+	if e, ok := n.Expansions[i]; ok {
+		return e.Name
+	}
+	return ""
+}
+
+func splitAbbr(s string) (string, string) {
+	m := abbrRe.FindStringSubmatch(s)
+	if m == nil {
+		return "", s
+	}
+	return m[1], m[2]
+}
+
+// genus returns a genus candidate from a name, or an empty string if the name
+// cannot provide a genus.
+func genus(n *output.Name) string {
+	if IsAbbreviated(n) {
+		return ""
+	}
+	ann, err := annotation.NewAnnotation(n.Annotation)
+	if err != nil || ann.In(annotation.NotName, annotation.Uninomial) {
+		return ""
+	}
+	words := strings.Fields(n.Name)
+	if len(words) == 0 {
+		return ""
+	}
+	if len(words) == 1 {
+		for _, v := range higherRankEnds {
+			if strings.HasSuffix(words[0], v) {
+				return ""
+			}
+		}
+	}
+	return words[0]
+}
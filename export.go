@@ -0,0 +1,36 @@
+package gntagger
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+)
+
+// ExportFile is the name of a tab-separated file with curated names, it is
+// created in the directory of a session.
+const ExportFile = "names.tsv"
+
+// Export writes names with their annotations into a tab-separated file.
+// Columns are the number of a name, the name-string, its expanded form,
+// the annotation, the type assigned by the name-finder, log-odds, and
+// offsets of the name in the text.
+func (n *Names) Export(path string) error {
+	var buf bytes.Buffer
+	buf.WriteString("Index\tName\tExpanded\tAnnotation\tType\tLogOdds\tStart\tEnd\n")
+	for i, v := range n.Data.Names {
+		logOdds := ""
+		if v.Odds != 0 {
+			logOdds = fmt.Sprintf("%0.2f", math.Log10(v.Odds))
+		}
+		fields := []string{
+			fmt.Sprintf("%d", i+1), v.Name, n.ExpandedName(i), v.Annotation,
+			v.Type, logOdds,
+			fmt.Sprintf("%d", v.OffsetStart), fmt.Sprintf("%d", v.OffsetEnd),
+		}
+		buf.WriteString(strings.Join(fields, "\t"))
+		buf.WriteString("\n")
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
@@ -30,6 +31,24 @@ func ShowWarningIfPreviousData(text *Text) {
 	}
 }
 
+// ShowWarningIfSettingsChanged warns if an existing session of the text was
+// created with other settings than settings of the GnTagger. Settings of the
+// session stay in use, only the express mode follows the GnTagger.
+func ShowWarningIfSettingsChanged(text *Text, gnt *GnTagger) {
+	old := previousDataChecksums(text)
+	if old.Settings == nil || old.Checksum != text.Checksum {
+		return
+	}
+	diff := old.Settings.Diff(gnt.Settings())
+	if len(diff) == 0 {
+		return
+	}
+	showWarning("\nThe session was created with other settings:\n  " +
+		strings.Join(diff, "\n  ") + "\nSettings of the session are used, " +
+		"except the express mode.\nTo change odds-high use " +
+		"'gntagger odds --apply'.")
+}
+
 func moveOldFiles(text *Text, timestamp string) {
 	for ft, f := range text.Files {
 		newPath := filepath.Join(text.Path, timestamp+"_"+f)
@@ -100,13 +119,12 @@ func createFilesGently(t *Text, names *Names) {
 		log.Panic(err)
 	}
 
-	err = ioutil.WriteFile(names.Path, names.Data.ToJSON(), 0644)
+	err = ioutil.WriteFile(names.Path, names.ToJSON(), 0644)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	err = ioutil.WriteFile(t.FilePath(MetaFile), t.TextMeta.ToJSON(), 0644)
-	if err != nil {
+	if err = t.SaveMeta(); err != nil {
 		log.Panic(err)
 	}
 	processedTextFromFile(t)
@@ -0,0 +1,141 @@
+package gntagger
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gnames/gnfinder/output"
+	"github.com/gnames/gntagger/annotation"
+)
+
+// Filter selects names by their annotations, types assigned by the
+// name-finder, and log-odds of the Bayes algorithm. Empty criteria select
+// all names.
+type Filter struct {
+	// Annotations of selected names.
+	Annotations []annotation.Annotation
+	// Types of selected names, compared case-insensitively.
+	Types []string
+	// Odds is true if names are selected by their log-odds. Names that were
+	// not scored by the Bayes algorithm are not selected then.
+	Odds bool
+	// LogOddsMin is the smallest log10 of odds of a selected name.
+	LogOddsMin float64
+	// LogOddsMax is the largest log10 of odds of a selected name.
+	LogOddsMax float64
+}
+
+// NewFilter parses a filter from a string of space-separated criteria:
+//
+// ann:NotName,Doubtful selects names by annotations ("new" means no
+// annotation);
+//
+// type:Uninomial,Binomial selects names by types;
+//
+// odds:-1..2 selects names with log-odds between -1 and 2, one of the
+// limits can be omitted.
+//
+// An empty string creates an empty filter.
+func NewFilter(s string) (*Filter, error) {
+	f := &Filter{LogOddsMin: math.Inf(-1), LogOddsMax: math.Inf(1)}
+	for _, term := range strings.Fields(s) {
+		kv := strings.SplitN(term, ":", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("filter '%s' should look like key:value", term)
+		}
+		switch kv[0] {
+		case "ann":
+			for _, v := range strings.Split(kv[1], ",") {
+				if v == "new" {
+					v = annotation.NotAssigned.String()
+				}
+				ann, err := annotation.NewAnnotation(v)
+				if err != nil {
+					return nil, err
+				}
+				f.Annotations = append(f.Annotations, ann)
+			}
+		case "type":
+			f.Types = append(f.Types, strings.Split(kv[1], ",")...)
+		case "odds":
+			if err := f.parseOdds(kv[1]); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unknown filter '%s', use ann, type or odds",
+				kv[0])
+		}
+	}
+	return f, nil
+}
+
+func (f *Filter) parseOdds(s string) error {
+	lim := strings.SplitN(s, "..", 2)
+	if len(lim) != 2 {
+		return fmt.Errorf("odds filter '%s' should look like min..max", s)
+	}
+	f.Odds = true
+	var err error
+	if lim[0] != "" {
+		if f.LogOddsMin, err = strconv.ParseFloat(lim[0], 64); err != nil {
+			return err
+		}
+	}
+	if lim[1] != "" {
+		if f.LogOddsMax, err = strconv.ParseFloat(lim[1], 64); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsEmpty returns true if the filter selects all names.
+func (f *Filter) IsEmpty() bool {
+	return len(f.Annotations) == 0 && len(f.Types) == 0 && !f.Odds
+}
+
+// Match returns true if a name satisfies all criteria of the filter.
+func (f *Filter) Match(n *output.Name) bool {
+	if len(f.Annotations) > 0 {
+		ann, err := annotation.NewAnnotation(n.Annotation)
+		if err != nil || !ann.In(f.Annotations...) {
+			return false
+		}
+	}
+	if len(f.Types) > 0 {
+		var ok bool
+		for _, t := range f.Types {
+			if strings.EqualFold(t, n.Type) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if f.Odds {
+		if n.Odds == 0 {
+			return false
+		}
+		lo := math.Log10(n.Odds)
+		if lo < f.LogOddsMin || lo > f.LogOddsMax {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns indices of names that match the filter, keeping the order
+// of given indices.
+func (n *Names) Apply(f *Filter, indices []int) []int {
+	res := make([]int, 0, len(indices))
+	for _, i := range indices {
+		if f.Match(&n.Data.Names[i]) {
+			res = append(res, i)
+		}
+	}
+	return res
+}
@@ -1,5 +1,15 @@
 package gntagger
 
+import (
+	"fmt"
+
+	"github.com/gnames/bayes"
+	"github.com/gnames/gnfinder/dict"
+	"github.com/gnames/gnfinder/lang"
+	"github.com/gnames/gntagger/history"
+	"github.com/gnames/gntagger/refdict"
+)
+
 // GnTagger keeps configuration parameters of the program
 type GnTagger struct {
 	// Bayes flag forces bayes name-finding even when the language of the text
@@ -13,9 +23,103 @@ type GnTagger struct {
 	// Express sets if we skip names that were already marked as 'good'
 	// or 'bad'
 	Express bool
+	// Language is the code of the language of texts, for example "eng". If
+	// it is DetectLanguage, the language of every text is detected.
+	Language string
+	// Dict is the dictionary of the name-finder. If it is nil, the
+	// dictionary is loaded for every text.
+	Dict *dict.Dictionary
+	// BayesWeights are weights of Bayes name-finding for every language.
+	// If they are nil, they are loaded for every text. They must not be
+	// loaded or used by several goroutines at the same time.
+	BayesWeights map[lang.Language]*bayes.NaiveBayes
+	// RefDict is an optional local reference dictionary used to verify found
+	// names offline. In express mode names that match it exactly are accepted
+	// automatically.
+	RefDict *refdict.RefDict
+	// History keeps decisions made in previously curated documents. If it is
+	// set, new documents are pre-annotated according to these decisions.
+	History *history.History
+	// Propagation is a policy for applying a decision about the current name
+	// to the following names.
+	Propagation Propagation
+	// PropagationPages is the distance in pages used by PropagatePages policy.
+	PropagationPages int
+	// Order is the sequence in which names are reviewed.
+	Order ReviewOrder
 }
 
 // NewGnTagger creates a new GnTagger object
 func NewGnTagger() *GnTagger {
-	return &GnTagger{OddsHigh: 100.0, OddsLow: 1, Express: true}
+	return &GnTagger{
+		OddsHigh:         100.0,
+		OddsLow:          1,
+		Express:          true,
+		Language:         DetectLanguage,
+		Propagation:      PropagateName,
+		PropagationPages: 5,
+	}
+}
+
+// Settings are options of a GnTagger that affect names of a session. They
+// are saved with the session.
+type Settings struct {
+	// Bayes forces bayes name-finding even when the language of the text is
+	// not supported.
+	Bayes bool `json:"bayes"`
+	// OddsHigh is the limit of odds below which names are doubtful.
+	OddsHigh float64 `json:"odds_high"`
+	// OddsLow is the limit of odds below which names are not found.
+	OddsLow float64 `json:"odds_low"`
+	// Express skips names that already have decisions. It does not affect
+	// found names, so Diff does not compare it.
+	Express bool `json:"express"`
+	// Language is the language of the text or DetectLanguage. It is empty
+	// in sessions created before the language could be set.
+	Language string `json:"language,omitempty"`
+}
+
+// Settings returns current settings of the GnTagger.
+func (gnt *GnTagger) Settings() Settings {
+	return Settings{
+		Bayes:    gnt.Bayes,
+		OddsHigh: gnt.OddsHigh,
+		OddsLow:  gnt.OddsLow,
+		Express:  gnt.Express,
+		Language: gnt.Language,
+	}
+}
+
+// UseSettings applies settings of a session that were used for finding
+// names. The express mode is not changed, because it does not affect
+// found names.
+func (gnt *GnTagger) UseSettings(s Settings) {
+	gnt.Bayes = s.Bayes
+	gnt.OddsHigh = s.OddsHigh
+	gnt.OddsLow = s.OddsLow
+	if s.Language != "" {
+		gnt.Language = s.Language
+	}
+}
+
+// Diff describes settings that differ from other settings.
+func (s Settings) Diff(other Settings) []string {
+	var res []string
+	if s.Bayes != other.Bayes {
+		res = append(res, fmt.Sprintf("bayes: %t (now %t)", s.Bayes,
+			other.Bayes))
+	}
+	if s.OddsHigh != other.OddsHigh {
+		res = append(res, fmt.Sprintf("odds-high: %g (now %g)", s.OddsHigh,
+			other.OddsHigh))
+	}
+	if s.OddsLow != other.OddsLow {
+		res = append(res, fmt.Sprintf("odds-low: %g (now %g)", s.OddsLow,
+			other.OddsLow))
+	}
+	if s.Language != "" && s.Language != other.Language {
+		res = append(res, fmt.Sprintf("lang: %s (now %s)", s.Language,
+			other.Language))
+	}
+	return res
 }
@@ -0,0 +1,112 @@
+// Copyright © 2019 Dmitry Mozzherin <dmozzherin@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/gnames/gnfinder/dict"
+	"github.com/gnames/gnfinder/nlp"
+	"github.com/gnames/gntagger"
+	"github.com/spf13/cobra"
+)
+
+// batchCmd creates sessions for all texts in a directory.
+var batchCmd = &cobra.Command{
+	Use:   "batch [flags] dir",
+	Short: "finds names in all texts of a directory",
+	Long: `batch finds names in all text files of a directory and its
+subdirectories, and creates a curation session next to every text,
+like gntagger does for one file.
+
+gntagger batch --jobs 4 volumes/
+
+Texts are processed concurrently. Texts with sessions of the same
+content are skipped, sessions of changed texts are backed up and
+created again.
+
+The summary of the batch is saved to gntagger_index.tsv in the
+directory. It lists every text with its checksum, the number of found
+names, the language and the status: created, updated, skipped or failed.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		jobs, err := flags.GetInt("jobs")
+		exitOnError(err)
+		width, err := flags.GetInt("width")
+		exitOnError(err)
+		ext, err := flags.GetString("ext")
+		exitOnError(err)
+
+		gnt := gntagger.NewGnTagger()
+		settingsConfig(cmd, gnt)
+		historyFlag(cmd, gnt)
+
+		dir := args[0]
+		paths, err := gntagger.BatchFiles(dir, ext)
+		exitOnError(err)
+		if len(paths) == 0 {
+			fmt.Printf("No %s files in %s\n", ext, dir)
+			return
+		}
+		gnt.Dict = dict.LoadDictionary()
+		gnt.BayesWeights = nlp.BayesWeights()
+		entries := gntagger.Batch(paths, jobs, width, version, gnt)
+
+		counts := make(map[string]int)
+		for i, v := range entries {
+			counts[v.Status]++
+			if v.Err != nil {
+				fmt.Printf("%s: %s\n", v.File, v.Err)
+			}
+			if rel, err := filepath.Rel(dir, v.File); err == nil {
+				entries[i].File = rel
+			}
+		}
+		index := filepath.Join(dir, gntagger.IndexFile)
+		exitOnError(gntagger.WriteIndex(index, entries))
+		fmt.Printf("\n%d texts: %d created, %d updated, %d skipped, "+
+			"%d failed.\nThe summary is saved to %s\n\n", len(entries),
+			counts[gntagger.BatchCreated], counts[gntagger.BatchUpdated],
+			counts[gntagger.BatchSkipped], counts[gntagger.BatchFailed], index)
+		if counts[gntagger.BatchFailed] > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+
+	batchCmd.Flags().IntP("jobs", "j", runtime.NumCPU(),
+		"number of texts processed at the same time.")
+	batchCmd.Flags().IntP("width", "w", 80,
+		"width of lines of processed texts.")
+	batchCmd.Flags().StringP("ext", "e", ".txt",
+		"extension of text files.")
+	batchCmd.Flags().Bool("no-history", false,
+		"do not use decisions made in other documents.")
+	settingsFlags(batchCmd)
+}
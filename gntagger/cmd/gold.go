@@ -0,0 +1,117 @@
+// Copyright © 2019 Dmitry Mozzherin <dmozzherin@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/gold"
+	"github.com/spf13/cobra"
+)
+
+// goldCmd compares a curated session with a gold standard.
+var goldCmd = &cobra.Command{
+	Use:   "gold [flags] session gold-file",
+	Short: "evaluates a session against a gold-standard annotation",
+	Long: `gold compares names found by gnfinder and names confirmed by
+the curator of a session with a gold standard, and reports true
+positives, false positives, false negatives, boundary errors, precision,
+recall and F1 for all names and for every type of names.
+
+gntagger gold book.txt_gntagger book_gold.json
+gntagger gold --overlap --min-overlap 0.5 book.txt_gntagger book.ann
+
+The gold standard is a names.json file in gnfinder's format, or a brat
+.ann file. Offsets of gold names must refer to input.txt of the
+session. Gold names that differ from the text at their offsets are
+listed separately, and neither they nor names overlapping them are
+compared. Only the part of the text
+reviewed by the curator is compared.
+
+Boundary errors are names that overlap with gold names, but start or
+end elsewhere. By default they are counted both as false positives and
+false negatives, with --overlap flag they are counted as true positives.
+`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		format, err := flags.GetString("format")
+		exitOnError(err)
+		types, err := flags.GetStringSlice("types")
+		exitOnError(err)
+		var opts gold.Options
+		opts.Overlap, err = flags.GetBool("overlap")
+		exitOnError(err)
+		opts.MinOverlap, err = flags.GetFloat64("min-overlap")
+		exitOnError(err)
+
+		t, n, err := gntagger.OpenSession(args[0])
+		exitOnError(err)
+		if format == "" {
+			format = "json"
+			if filepath.Ext(args[1]) == ".ann" {
+				format = "brat"
+			}
+		}
+		var spans []gold.Span
+		switch format {
+		case "json":
+			spans, err = gold.FromJSON(args[1])
+		case "brat":
+			spans, err = gold.FromBrat(args[1], types...)
+		default:
+			err = fmt.Errorf("unknown format '%s', use 'json' or 'brat'",
+				format)
+		}
+		exitOnError(err)
+
+		curated, limit := gold.Curated(t, n)
+		spans, mismatched := gold.Check(gold.Before(spans, limit),
+			t.Processed)
+		found := gold.Outside(gold.Before(gold.Found(n, sessionGnTagger(t)),
+			limit), mismatched)
+		curated = gold.Outside(curated, mismatched)
+		fmt.Printf("# %s\n\nGold names in the reviewed text: %d\n\n",
+			t.Path, len(spans))
+		if len(mismatched) > 0 {
+			fmt.Printf("Gold names that do not match the text: %d\n\n",
+				len(mismatched))
+			fmt.Println(gold.FormatMismatched(mismatched, t.Processed))
+		}
+		fmt.Println(gold.Format("gnfinder", gold.Compare(spans, found, opts)))
+		fmt.Println(gold.Format("curator", gold.Compare(spans, curated, opts)))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(goldCmd)
+
+	goldCmd.Flags().StringP("format", "f", "",
+		"format of the gold file: 'json' or 'brat' (by default 'brat' for .ann files).")
+	goldCmd.Flags().StringSliceP("types", "t", nil,
+		"brat entity types of names, all types by default.")
+	goldCmd.Flags().Bool("overlap", false,
+		"count names that overlap with gold names as found.")
+	goldCmd.Flags().Float64("min-overlap", 0,
+		"the smallest share of the longer name covered by the intersection.")
+}
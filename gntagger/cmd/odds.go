@@ -0,0 +1,130 @@
+// Copyright © 2019 Dmitry Mozzherin <dmozzherin@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/report"
+	"github.com/spf13/cobra"
+)
+
+// oddsCmd shows how the limit of doubtful names changes precision and
+// recall, and applies a new limit to sessions.
+var oddsCmd = &cobra.Command{
+	Use:   "odds [flags] session...",
+	Short: "finds the odds limit of doubtful names from curated sessions",
+	Long: `odds calculates precision and recall of name-finding in curated
+sessions for a range of thresholds of log-odds. Names with odds below
+a threshold are doubtful: they count as missed by the name-finder if
+a curator accepted them.
+
+gntagger odds book1.txt_gntagger book2.txt_gntagger
+
+The command suggests the threshold with the highest recall among
+thresholds with precision not lower than --precision.
+
+With --apply flag the given odds become the limit of doubtful names of
+the sessions. Names without decisions are marked as Doubtful or lose
+the Doubtful mark according to the new limit:
+
+gntagger odds --apply 30 book1.txt_gntagger
+`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		precision, err := flags.GetFloat32("precision")
+		exitOnError(err)
+		min, err := flags.GetFloat64("min")
+		exitOnError(err)
+		max, err := flags.GetFloat64("max")
+		exitOnError(err)
+		step, err := flags.GetFloat64("step")
+		exitOnError(err)
+		apply, err := flags.GetFloat64("apply")
+		exitOnError(err)
+		if step <= 0 || min > max {
+			fmt.Println("The step must be positive and min not above max.")
+			os.Exit(1)
+		}
+
+		if apply > 0 {
+			for _, v := range args {
+				applyOddsHigh(v, apply)
+			}
+			return
+		}
+
+		names := make([]*gntagger.Names, 0, len(args))
+		for _, v := range args {
+			_, n, err := gntagger.OpenSession(v)
+			exitOnError(err)
+			names = append(names, n)
+		}
+		points, err := report.Curve(names, min, max, step)
+		exitOnError(err)
+		fmt.Println(report.FormatCurve(points))
+		if p, ok := report.Suggest(points, precision); ok {
+			fmt.Printf("Precision %.3f needs odds of at least %.4g "+
+				"(log-odds %.2f), recall %.3f.\n", precision, p.Odds(),
+				p.LogOdds, p.Recall)
+		} else {
+			fmt.Printf("No threshold gives precision %.3f.\n", precision)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(oddsCmd)
+
+	oddsCmd.Flags().Float32P("precision", "p", 0.95,
+		"target precision for the suggested threshold.")
+	oddsCmd.Flags().Float64("min", -1, "the lowest threshold in log-odds.")
+	oddsCmd.Flags().Float64("max", 5, "the highest threshold in log-odds.")
+	oddsCmd.Flags().Float64("step", 0.25, "the step of thresholds in log-odds.")
+	oddsCmd.Flags().Float64P("apply", "a", 0,
+		"makes the odds the limit of doubtful names of the sessions.")
+}
+
+// applyOddsHigh reclassifies names of a session by a new limit of doubtful
+// names and saves the limit in the session.
+func applyOddsHigh(path string, oddsHigh float64) {
+	t, n, err := gntagger.OpenSession(path)
+	exitOnError(err)
+	gnt := sessionGnTagger(t)
+	gnt.OddsHigh = oddsHigh
+	count := n.Reclassify(gnt)
+	exitOnError(n.Save())
+	t.SetSettings(gnt)
+	exitOnError(t.SaveMeta())
+	fmt.Printf("%s: odds limit %g (log-odds %.2f), %d names changed\n",
+		t.Path, oddsHigh, math.Log10(oddsHigh), count)
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
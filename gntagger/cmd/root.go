@@ -26,14 +26,23 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/history"
+	"github.com/gnames/gntagger/keymap"
+	"github.com/gnames/gntagger/refdict"
 	"github.com/gnames/gntagger/termui"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// settingsKeys are names of flags and configuration keys that set
+// options of name-finding.
+var settingsKeys = []string{"bayes", "odds-high", "odds-low", "express",
+	"lang"}
+
 var (
 	version string
 	build   string
@@ -64,6 +73,8 @@ a ./gntagger_input directory.
 
 Documentation: https://godoc.org/github.com/gnames/gntagger
 `,
+	// a file name is not a subcommand
+	Args: cobra.ArbitraryArgs,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
@@ -74,6 +85,10 @@ Documentation: https://godoc.org/github.com/gnames/gntagger
 		versionFlag(cmd)
 
 		gnt := gntagger.NewGnTagger()
+		settingsConfig(cmd, gnt)
+		refFlag(cmd, gnt)
+		historyFlag(cmd, gnt)
+		km := keymapConfig()
 
 		switch len(args) {
 		case 0:
@@ -99,7 +114,8 @@ Documentation: https://godoc.org/github.com/gnames/gntagger
 
 		text := gntagger.NewText(data, path, version)
 		gntagger.ShowWarningIfPreviousData(text)
-		termui.InitGUI(text, gnt)
+		gntagger.ShowWarningIfSettingsChanged(text, gnt)
+		termui.InitGUI(text, gnt, km)
 		defer infoOnExit(text)
 	},
 }
@@ -126,6 +142,14 @@ func init() {
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("version", "V", false, "show version and build timestamp.")
+	rootCmd.Flags().StringP("ref", "r", "",
+		"verify names against a local list (Darwin Core table or one name per line).")
+	rootCmd.Flags().Bool("no-history", false,
+		"do not use or update decisions made in other documents.")
+	rootCmd.Flags().Int("propagation-pages", gntagger.NewGnTagger().PropagationPages,
+		"distance in pages for the 'pages' propagation policy.")
+
+	settingsFlags(rootCmd)
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -188,3 +212,119 @@ func versionFlag(cmd *cobra.Command) {
 		os.Exit(0)
 	}
 }
+
+func refFlag(cmd *cobra.Command, gnt *gntagger.GnTagger) {
+	path, err := cmd.Flags().GetString("ref")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if path == "" {
+		return
+	}
+	rd, err := refdict.NewRefDict(path)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	gnt.RefDict = rd
+}
+
+func historyFlag(cmd *cobra.Command, gnt *gntagger.GnTagger) {
+	noHistory, err := cmd.Flags().GetBool("no-history")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if noHistory {
+		return
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	h, err := history.NewHistory(filepath.Join(home, ".gntagger", "history.json"))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	gnt.History = h
+}
+
+// keymapConfig creates key bindings from the "keys" section of the
+// configuration file. The program exits if the bindings are invalid.
+func keymapConfig() keymap.Keymap {
+	km, err := keymap.NewKeymap(viper.GetStringMapStringSlice("keys"))
+	if err != nil {
+		fmt.Printf("Wrong keys in %s: %s\n", viper.ConfigFileUsed(), err)
+		os.Exit(1)
+	}
+	return km
+}
+
+// settingsFlags adds flags of name-finding options to a command.
+func settingsFlags(cmd *cobra.Command) {
+	gnt := gntagger.NewGnTagger()
+	cmd.Flags().BoolP("bayes", "b", gnt.Bayes,
+		"use Bayes name-finding even if the language of the text is not supported.")
+	cmd.Flags().Float64("odds-high", gnt.OddsHigh,
+		"names with lower odds are marked as doubtful.")
+	cmd.Flags().Float64("odds-low", gnt.OddsLow,
+		"names with lower odds are not found.")
+	cmd.Flags().Bool("express", gnt.Express,
+		"skip names that already have decisions.")
+	cmd.Flags().StringP("lang", "l", gnt.Language,
+		fmt.Sprintf("language of the text (%s), or '%s' to detect it.",
+			strings.Join(gntagger.Languages(), ", "), gntagger.DetectLanguage))
+}
+
+// settingsConfig reads options of name-finding from flags of a command, or
+// from the configuration file if flags are not given. Commands with
+// a propagation-pages flag read the distance of the 'pages' propagation
+// policy the same way. The program exits if the limits of odds or the
+// distance are invalid.
+func settingsConfig(cmd *cobra.Command, gnt *gntagger.GnTagger) {
+	for _, v := range settingsKeys {
+		if err := viper.BindPFlag(v, cmd.Flags().Lookup(v)); err != nil {
+			log.Panic(err)
+		}
+	}
+	gnt.Bayes = viper.GetBool("bayes")
+	gnt.OddsHigh = viper.GetFloat64("odds-high")
+	gnt.OddsLow = viper.GetFloat64("odds-low")
+	gnt.Express = viper.GetBool("express")
+	gnt.Language = viper.GetString("lang")
+	if err := gntagger.CheckLanguage(gnt.Language); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if gnt.OddsLow <= 0 || gnt.OddsHigh < gnt.OddsLow {
+		fmt.Printf("Odds limits must be positive, and odds-high not lower "+
+			"than odds-low (%g, %g)\n", gnt.OddsHigh, gnt.OddsLow)
+		os.Exit(1)
+	}
+
+	f := cmd.Flags().Lookup("propagation-pages")
+	if f == nil {
+		return
+	}
+	if err := viper.BindPFlag(f.Name, f); err != nil {
+		log.Panic(err)
+	}
+	gnt.PropagationPages = viper.GetInt(f.Name)
+	if gnt.PropagationPages < 0 {
+		fmt.Printf("Propagation pages must not be negative (%d)\n",
+			gnt.PropagationPages)
+		os.Exit(1)
+	}
+}
+
+// sessionGnTagger returns settings used for curation of a session.
+func sessionGnTagger(t *gntagger.Text) *gntagger.GnTagger {
+	gnt := gntagger.NewGnTagger()
+	if t.Settings != nil {
+		gnt.UseSettings(*t.Settings)
+	}
+	return gnt
+}
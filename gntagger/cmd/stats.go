@@ -0,0 +1,109 @@
+// Copyright © 2019 Dmitry Mozzherin <dmozzherin@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/report"
+	"github.com/spf13/cobra"
+)
+
+// statsCmd reports the quality of name-finding in curated sessions.
+var statsCmd = &cobra.Command{
+	Use:   "stats [flags] session...",
+	Short: "reports precision and recall of name-finding in curated sessions",
+	Long: `stats evaluates names found by gnfinder using decisions made
+during curation, and reports precision, recall and F1 for every
+session and for all of them together.
+
+gntagger stats book1.txt_gntagger book2.txt_gntagger
+
+Only names reviewed by the curator are counted.
+Besides totals, the report contains numbers of names with every
+annotation, and precision and recall for every type of names assigned
+by gnfinder and for ranges of odds.
+
+The report is written in JSON (default) or Markdown format to STDOUT
+or to a file given by --out flag.
+`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		out, err := cmd.Flags().GetString("out")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if format != "json" && format != "md" {
+			fmt.Printf("Unknown format '%s', use 'json' or 'md'\n", format)
+			os.Exit(1)
+		}
+
+		docs := make([]*report.Document, 0, len(args))
+		for _, v := range args {
+			t, n, err := gntagger.OpenSession(v)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			d, err := report.NewDocument(t, n, sessionGnTagger(t))
+			if err != nil {
+				fmt.Printf("%s: %s\n", v, err)
+				os.Exit(1)
+			}
+			docs = append(docs, d)
+		}
+		r := report.New(docs)
+
+		var res []byte
+		if format == "md" {
+			res = []byte(r.Markdown())
+		} else if res, err = r.JSON(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if out == "" {
+			fmt.Println(string(res))
+			return
+		}
+		if err = ioutil.WriteFile(out, res, 0644); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringP("format", "f", "json",
+		"format of the report: 'json' or 'md' (Markdown).")
+	statsCmd.Flags().StringP("out", "o", "",
+		"file for the report, STDOUT by default.")
+}
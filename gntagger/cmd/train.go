@@ -0,0 +1,123 @@
+// Copyright © 2019 Dmitry Mozzherin <dmozzherin@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gnames/gnfinder/dict"
+	"github.com/gnames/gnfinder/nlp"
+	"github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/train"
+	"github.com/spf13/cobra"
+)
+
+// trainCmd creates Bayes training data from curated sessions.
+var trainCmd = &cobra.Command{
+	Use:   "train [flags] session...",
+	Short: "creates Bayes training data for gnfinder from curated sessions",
+	Long: `train collects names confirmed during curation and creates
+training data and Bayes weights in gnfinder's format.
+
+gntagger train book1.txt_gntagger book2.txt_gntagger
+
+The new model is compared with the default one on held-out sessions
+(given by --holdout flag, or the last session, if there are several).
+Held-out sessions are not used for training.
+
+Only the part of a text before its first unreviewed name is used, because
+the rest of the text may contain names without decisions. A warning shows
+sessions where reviewed names follow an unreviewed one and are left out.
+
+The output directory contains a subdirectory for every language with
+names.txt, names.json, no_names.txt, no_names.json files (gnfinder's
+'data/training' format) and bayes.json (gnfinder's 'data/files/nlp'
+format).
+`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		out, err := cmd.Flags().GetString("out")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		holdout, err := cmd.Flags().GetStringSlice("holdout")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		oddsHigh := gntagger.NewGnTagger().OddsHigh
+
+		if len(holdout) == 0 && len(args) > 1 {
+			holdout = args[len(args)-1:]
+			args = args[:len(args)-1]
+		}
+		cs := curatedSessions(args)
+		tests := curatedSessions(holdout)
+		if len(tests) == 0 {
+			fmt.Println("\nNo held-out sessions, evaluating on training data.")
+			tests = cs
+		}
+
+		d := dict.LoadDictionary()
+		weights := train.Train(cs, d)
+		if err = train.Write(out, cs, weights); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nTraining data are saved to %s\n\n", out)
+
+		old := nlp.BayesWeights()
+		for _, c := range tests {
+			fmt.Printf("%s\n  old: %s\n  new: %s\n\n", c.Path,
+				train.Evaluate(c, old, d, oddsHigh),
+				train.Evaluate(c, weights, d, oddsHigh))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trainCmd)
+
+	trainCmd.Flags().StringP("out", "o", "gntagger_training",
+		"directory for training data.")
+	trainCmd.Flags().StringSliceP("holdout", "t", nil,
+		"sessions used only for evaluation of the new model.")
+}
+
+func curatedSessions(paths []string) []*train.Curated {
+	res := make([]*train.Curated, 0, len(paths))
+	for _, v := range paths {
+		t, n, err := gntagger.OpenSession(v)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		c := train.NewCurated(t, n)
+		if c.Dropped > 0 {
+			fmt.Printf("\nWarning: %s: %d of %d reviewed names follow an "+
+				"unreviewed name and are not used.\n", v, c.Dropped, c.Reviewed)
+		}
+		res = append(res, c)
+	}
+	return res
+}
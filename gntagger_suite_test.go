@@ -14,6 +14,8 @@ const (
 	pathLong       = "./testdata/seashells_book.txt"
 	pathShort      = "./testdata/short.txt"
 	pathNamesAnnot = "./testdata/names_annot.json"
+	pathRefDwC     = "./testdata/ref_dwc.tsv"
+	pathRefList    = "./testdata/ref_list.txt"
 )
 
 var (
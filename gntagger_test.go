@@ -1,11 +1,17 @@
 package gntagger_test
 
 import (
-	"github.com/gnames/gnfinder"
+	"github.com/gnames/gnfinder/output"
 	. "github.com/gnames/gntagger"
 	"github.com/gnames/gntagger/annotation"
+	"github.com/gnames/gntagger/history"
+	"github.com/gnames/gntagger/refdict"
 
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -25,7 +31,7 @@ var _ = Describe("Gntagger", func() {
 			It("cleans, wraps a text, and adds it to Processed field", func() {
 				t := NewText(dataLong, pathLong, "abcd")
 				t.Process(40)
-				Expect(len(t.Processed)).To(Equal(1112796))
+				Expect(len(t.Processed)).To(Equal(1112308))
 			})
 		})
 
@@ -66,6 +72,70 @@ var _ = Describe("Gntagger", func() {
 				})
 			})
 
+			Describe("Reclassify", func() {
+				It("applies a new odds limit to names without decisions", func() {
+					names := namesForAnnotations()
+					ns := names.Data.Names
+					ns[0].Annotation = annotation.NotName.String()
+					gnt := NewGnTagger()
+					gnt.OddsHigh = 300
+					Expect(names.Reclassify(gnt)).To(Equal(1))
+					Expect(ns[0].Annotation).To(Equal("NotName"))
+					Expect(ns[5].Annotation).To(Equal("Doubtful"))
+					Expect(ns[10].Annotation).To(Equal(""))
+					Expect(ns[11].Annotation).To(Equal("Doubtful"))
+					gnt.OddsHigh = 10
+					Expect(names.Reclassify(gnt)).To(Equal(5))
+					Expect(ns[5].Annotation).To(Equal(""))
+					Expect(ns[11].Annotation).To(Equal(""))
+				})
+
+				It("keeps settings of a session", func() {
+					dir, err := ioutil.TempDir("", "gntagger_odds")
+					Expect(err).ToNot(HaveOccurred())
+					defer os.RemoveAll(dir)
+					short := NewText(dataShort, filepath.Join(dir, "short.txt"),
+						"abcd")
+					gntShort := NewGnTagger()
+					s := NewSession(short, PrepareFilesAndText(short, 100-36,
+						gntShort), gntShort)
+					Expect(*s.Text.Settings).To(Equal(NewGnTagger().Settings()))
+					s.Text.Settings.OddsHigh = 5000
+					s.Text.Settings.Bayes = true
+					Expect(s.Text.SaveMeta()).To(Succeed())
+
+					t, _, err := OpenSession(s.Text.Path)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(t.Settings.OddsHigh).To(Equal(5000.0))
+					gnt := NewGnTagger()
+					gnt.Express = false
+					t = NewText(dataShort, filepath.Join(dir, "short.txt"), "abcd")
+					PrepareFilesAndText(t, 100-36, gnt)
+					Expect(gnt.OddsHigh).To(Equal(5000.0))
+					Expect(gnt.Bayes).To(BeTrue())
+					Expect(gnt.Express).To(BeFalse())
+
+					t, _, err = OpenSession(s.Text.Path)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(t.Settings.Express).To(BeFalse())
+				})
+			})
+
+			Describe("Settings", func() {
+				It("describes differences of settings", func() {
+					gnt := NewGnTagger()
+					s := gnt.Settings()
+					Expect(s.Diff(gnt.Settings())).To(BeEmpty())
+					gnt.OddsHigh = 30
+					gnt.Express = false
+					Expect(s.Diff(gnt.Settings())).
+						To(Equal([]string{"odds-high: 100 (now 30)"}))
+					gnt.UseSettings(s)
+					Expect(gnt.OddsHigh).To(Equal(100.0))
+					Expect(gnt.Express).To(BeFalse())
+				})
+			})
+
 			Describe("UpdateAnnotations", func() {
 				/* Test name data
 				    n name                  odds   annot
@@ -144,6 +214,414 @@ var _ = Describe("Gntagger", func() {
 	})
 })
 
+var _ = Describe("Expansion", func() {
+	Describe("ExpandAbbreviations", func() {
+		It("expands abbreviations with the nearest preceding genus", func() {
+			names := namesForExpansions()
+			names.ExpandAbbreviations()
+			Expect(names.ExpandedName(0)).To(Equal(""))
+			Expect(names.ExpandedName(2)).To(Equal("Octopus vulgaris"))
+			Expect(names.ExpandedName(4)).To(Equal("Ocythoe tuberculata"))
+			Expect(names.ExpandedName(5)).To(Equal("Argonauta argo"))
+		})
+
+		It("ignores genera of rejected names", func() {
+			names := namesForExpansions()
+			names.Data.Names[3].Annotation = annotation.NotName.String()
+			names.ExpandAbbreviations()
+			Expect(names.ExpandedName(4)).To(Equal("Octopus tuberculata"))
+		})
+	})
+
+	Describe("CycleExpansion", func() {
+		It("cycles through candidate genera and no expansion", func() {
+			names := namesForExpansions()
+			names.ExpandAbbreviations()
+			Expect(names.ExpansionCandidates(4)).
+				To(Equal([]string{"Ocythoe", "Octopus"}))
+			names.Data.Meta.CurrentName = 4
+			names.CycleExpansion()
+			Expect(names.ExpandedName(4)).To(Equal("Octopus tuberculata"))
+			names.CycleExpansion()
+			Expect(names.ExpandedName(4)).To(Equal(""))
+			names.ExpandAbbreviations()
+			Expect(names.ExpandedName(4)).To(Equal(""))
+			names.CycleExpansion()
+			Expect(names.ExpandedName(4)).To(Equal("Ocythoe tuberculata"))
+		})
+	})
+
+	Describe("NamesFromJSON", func() {
+		It("restores expansions saved with names", func() {
+			names := namesForExpansions()
+			names.Path = filepath.Join(os.TempDir(), "gntagger_names.json")
+			defer os.Remove(names.Path)
+			names.ExpandAbbreviations()
+			names.Data.Meta.CurrentName = 2
+			names.CycleExpansion()
+			Expect(names.Save()).To(Succeed())
+			res := NamesFromJSON(names.Path)
+			Expect(res.Data.Names).To(HaveLen(6))
+			Expect(res.Expansions[2]).To(Equal(&Expansion{Curated: true}))
+			Expect(res.ExpandedName(4)).To(Equal("Ocythoe tuberculata"))
+		})
+
+		It("restores reviewed names", func() {
+			names := namesForExpansions()
+			names.Path = filepath.Join(os.TempDir(), "gntagger_names.json")
+			defer os.Remove(names.Path)
+			names.Data.Meta.CurrentName = 2
+			Expect(names.Save()).To(Succeed())
+			Expect(NamesFromJSON(names.Path).Reviewed).To(BeEmpty())
+			names.Reviewed = map[int]bool{4: true}
+			names.Passed = map[int]bool{5: true}
+			Expect(names.Save()).To(Succeed())
+			res := NamesFromJSON(names.Path)
+			Expect(res.Reviewed).To(Equal(map[int]bool{4: true}))
+			Expect(res.Passed).To(Equal(map[int]bool{5: true}))
+		})
+	})
+})
+
+var _ = Describe("Verification", func() {
+	Describe("Verify", func() {
+		It("matches names and their expansions to a reference", func() {
+			rd, err := refdict.NewRefDict(pathRefList)
+			Expect(err).ToNot(HaveOccurred())
+			names := namesForExpansions()
+			names.ExpandAbbreviations()
+			names.Verify(rd)
+			Expect(names.Matches).To(Equal([]refdict.Match{refdict.Exact,
+				refdict.NoMatch, refdict.NoMatch, refdict.NoMatch, refdict.Exact,
+				refdict.NoMatch}))
+		})
+
+		It("matches names again when their expansion changes", func() {
+			rd, err := refdict.NewRefDict(pathRefList)
+			Expect(err).ToNot(HaveOccurred())
+			names := namesForExpansions()
+			names.ExpandAbbreviations()
+			names.Verify(rd)
+			names.Data.Meta.CurrentName = 4
+			names.CycleExpansion()
+			Expect(names.ExpandedName(4)).To(Equal("Octopus tuberculata"))
+			Expect(names.Matches[4]).To(Equal(refdict.NoMatch))
+			names.CycleExpansion()
+			names.CycleExpansion()
+			Expect(names.ExpandedName(4)).To(Equal("Ocythoe tuberculata"))
+			Expect(names.Matches[4]).To(Equal(refdict.Exact))
+		})
+	})
+
+	Describe("AutoAccept", func() {
+		It("accepts only exact unreviewed matches", func() {
+			rd, err := refdict.NewRefDict(pathRefList)
+			Expect(err).ToNot(HaveOccurred())
+			names := namesForExpansions()
+			names.ExpandAbbreviations()
+			Expect(names.AutoAccept(0)).To(BeFalse())
+			names.Verify(rd)
+			names.Data.Names[4].Annotation = annotation.NotName.String()
+			Expect(names.AutoAccept(0)).To(BeTrue())
+			Expect(names.Data.Names[0].Annotation).
+				To(Equal(annotation.Accepted.String()))
+			Expect(names.AutoAccept(1)).To(BeFalse())
+			Expect(names.AutoAccept(4)).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("PreAnnotate", func() {
+	It("annotates names according to decisions in other documents", func() {
+		names := namesForAnnotations()
+		ns := names.Data.Names
+		names.Past = map[string]history.Counts{
+			"Venus":      {"NotName": 3},
+			"Gastropoda": {"Accepted": 1, "Uninomial": 2},
+			"Octopus":    {"Accepted": 1, "NotName": 1},
+			"Mollusca":   {"NotName": 1},
+		}
+		ns[11].Annotation = annotation.Species.String()
+		names.PreAnnotate()
+		Expect(ns[0].Annotation).To(Equal(annotation.Accepted.String()))
+		Expect(ns[11].Annotation).To(Equal(annotation.Species.String()))
+		Expect(ns[5].Annotation).To(Equal(annotation.Doubtful.String()))
+		Expect(ns[7].Annotation).To(Equal(annotation.NotName.String()))
+		Expect(ns[9].Annotation).To(Equal(annotation.NotName.String()))
+		Expect(ns[10].Annotation).To(Equal(annotation.NotAssigned.String()))
+	})
+})
+
+var _ = Describe("Propagation", func() {
+	Describe("UpdateAnnotations", func() {
+		It("does not propagate with PropagateNone", func() {
+			names := namesForAnnotations()
+			ns := names.Data.Names
+			gnt := NewGnTagger()
+			gnt.Propagation = PropagateNone
+			names.Data.Meta.CurrentName = 7
+			count, err := names.UpdateAnnotations(annotation.NotName, 7, gnt)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(0))
+			Expect(ns[8].Annotation).To(Equal(annotation.Doubtful.String()))
+		})
+
+		It("propagates to names of the same type", func() {
+			names := namesForAnnotations()
+			ns := names.Data.Names
+			ns[9].Type = "Uninomial"
+			gnt := NewGnTagger()
+			gnt.Propagation = PropagateNameType
+			names.Data.Meta.CurrentName = 7
+			count, err := names.UpdateAnnotations(annotation.NotName, 7, gnt)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(1))
+			Expect(ns[8].Annotation).To(Equal(annotation.NotName.String()))
+			Expect(ns[9].Annotation).To(Equal(annotation.Doubtful.String()))
+		})
+
+		It("propagates to names within a number of pages", func() {
+			names := namesForAnnotations()
+			ns := names.Data.Names
+			names.Pages = []int{0, ns[8].OffsetStart, ns[9].OffsetStart}
+			gnt := NewGnTagger()
+			gnt.Propagation = PropagatePages
+			gnt.PropagationPages = 1
+			names.Data.Meta.CurrentName = 7
+			count, err := names.UpdateAnnotations(annotation.NotName, 7, gnt)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(1))
+			Expect(ns[8].Annotation).To(Equal(annotation.NotName.String()))
+			Expect(ns[9].Annotation).To(Equal(annotation.Doubtful.String()))
+		})
+
+		It("keeps annotations of reviewed names", func() {
+			names := namesForAnnotations()
+			ns := names.Data.Names
+			names.Data.Meta.CurrentName = 7
+			names.Reviewed = map[int]bool{8: true}
+			count, err := names.UpdateAnnotations(annotation.NotName, 7,
+				NewGnTagger())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(1))
+			Expect(ns[8].Annotation).To(Equal(annotation.Doubtful.String()))
+			Expect(ns[9].Annotation).To(Equal(annotation.NotName.String()))
+		})
+	})
+
+	Describe("Next", func() {
+		It("cycles through policies", func() {
+			Expect(PropagatePages.Next()).To(Equal(PropagateNone))
+			Expect(PropagateNone.Next()).To(Equal(PropagateName))
+		})
+	})
+
+	Describe("PageStarts", func() {
+		It("splits text by form feeds", func() {
+			t := NewText([]byte("one\ftwo\nthree\f"), "", "abcd")
+			t.Processed = []rune(string(t.Raw))
+			Expect(t.PageStarts()).To(Equal([]int{0, 4, 14}))
+		})
+
+		It("splits text without form feeds by lines", func() {
+			t := NewText(nil, "", "abcd")
+			for i := 0; i < LinesPerPage*2+1; i++ {
+				t.Processed = append(t.Processed, 'a', '\n')
+			}
+			Expect(t.PageStarts()).
+				To(Equal([]int{0, LinesPerPage * 2, LinesPerPage * 4}))
+		})
+	})
+})
+
+var _ = Describe("Order", func() {
+	It("keeps document order by default", func() {
+		names := namesForAnnotations()
+		gnt := NewGnTagger()
+		Expect(names.Order(gnt)).
+			To(Equal([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}))
+	})
+
+	It("puts uncertain first occurrences first", func() {
+		names := namesForAnnotations()
+		gnt := NewGnTagger()
+		gnt.Order = OrderInformative
+		Expect(names.Order(gnt)).
+			To(Equal([]int{0, 5, 10, 7, 1, 2, 3, 4, 6, 8, 9, 11}))
+	})
+
+	It("puts names with conflicting decisions first", func() {
+		names := namesForAnnotations()
+		names.Past = map[string]history.Counts{
+			"Venus": {"NotName": 3, "Genus": 1},
+		}
+		names.Data.Names[3].Annotation = annotation.NotName.String()
+		names.Data.Names = append(names.Data.Names, names.Data.Names[3])
+		names.Data.Names[12].Annotation = annotation.Accepted.String()
+		Expect(names.InformativeOrder(NewGnTagger())).
+			To(Equal([]int{7, 3, 0, 5, 10, 1, 2, 4, 6, 8, 9, 11, 12}))
+	})
+})
+
+var _ = Describe("Unique", func() {
+	Describe("Uniques", func() {
+		It("groups occurrences of name-strings", func() {
+			names := namesForAnnotations()
+			us := names.Uniques()
+			Expect(len(us)).To(Equal(9))
+			Expect(us[0]).To(Equal(Unique{Name: "Gastropoda",
+				Indices: []int{0, 11}}))
+			Expect(us[7]).To(Equal(Unique{Name: "Venus",
+				Indices: []int{7, 8, 9}}))
+		})
+	})
+
+	Describe("AnnotateAll", func() {
+		It("annotates all occurrences except exceptions", func() {
+			names := namesForAnnotations()
+			u := &names.Uniques()[7]
+			Expect(names.IsReviewed(u)).To(BeFalse())
+			names.AnnotateAll(u, annotation.Genus, map[int]bool{8: true})
+			Expect(names.IsReviewed(u)).To(BeTrue())
+			Expect(names.Annotations(u)).
+				To(Equal(map[annotation.Annotation]int{
+					annotation.Genus: 2, annotation.NotName: 1}))
+			Expect(names.Data.Names[8].Annotation).To(Equal("NotName"))
+
+			names.AnnotateAll(u, annotation.NotName, map[int]bool{9: true})
+			Expect(names.Data.Names[9].Annotation).To(Equal("Accepted"))
+		})
+	})
+
+	Describe("UniqueStrings", func() {
+		It("shows occurrences count and mixed annotations", func() {
+			names := namesForAnnotations()
+			u := &names.Uniques()[0]
+			strs := names.UniqueStrings(u, 0, 9, false, 0, nil)
+			Expect(strs[0]).To(Equal("    1/9 (x2)"))
+			names.Data.Names[11].Annotation = annotation.NotName.String()
+			strs = names.UniqueStrings(u, 0, 9, true, 1, map[int]bool{11: true})
+			Expect(strs[0]).To(Equal("    1/9 (2* of 2, 1 exc.)"))
+			Expect(strs[3]).To(ContainSubstring("1 new"))
+			Expect(strs[3]).To(ContainSubstring("1 NotName"))
+		})
+	})
+})
+
+var _ = Describe("Search", func() {
+	It("finds names by name-strings and by text", func() {
+		t := &Text{Processed: []rune("Über Venus mercenaria and Octopus, " +
+			"then Venus again near the shore.")}
+		o := output.Output{Names: []output.Name{
+			{Name: "Venus mercenaria", OffsetStart: 5, OffsetEnd: 21},
+			{Name: "Octopus", OffsetStart: 26, OffsetEnd: 33},
+			{Name: "Venus", OffsetStart: 40, OffsetEnd: 45},
+		}}
+		names := &Names{Data: o}
+		Expect(names.Search(t, regexp.MustCompile("^Venus"))).
+			To(Equal([]int{0, 2}))
+		Expect(names.Search(t, regexp.MustCompile("then"))).
+			To(Equal([]int{2}))
+		Expect(names.Search(t, regexp.MustCompile("shore"))).
+			To(Equal([]int{}))
+		Expect(names.Search(t, regexp.MustCompile("Üb|cto"))).
+			To(Equal([]int{0, 1}))
+	})
+
+	It("finds next and previous matches", func() {
+		ms := []int{2, 5, 9}
+		Expect(NextMatch(ms, 5, true)).To(Equal(9))
+		Expect(NextMatch(ms, 9, true)).To(Equal(2))
+		Expect(NextMatch(ms, 5, false)).To(Equal(2))
+		Expect(NextMatch(ms, 1, false)).To(Equal(9))
+		Expect(NextMatch(nil, 1, true)).To(Equal(-1))
+	})
+})
+
+var _ = Describe("Filter", func() {
+	It("parses filters", func() {
+		f, err := NewFilter("ann:NotName,new type:uninomial odds:-1..")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Annotations).
+			To(Equal([]annotation.Annotation{annotation.NotName,
+				annotation.NotAssigned}))
+		Expect(f.Types).To(Equal([]string{"uninomial"}))
+		Expect(f.Odds).To(BeTrue())
+		Expect(f.LogOddsMin).To(Equal(-1.0))
+		Expect(f.IsEmpty()).To(BeFalse())
+
+		f, err = NewFilter("  ")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.IsEmpty()).To(BeTrue())
+
+		for _, v := range []string{"ann", "ann:Maybe", "size:2", "odds:1"} {
+			_, err = NewFilter(v)
+			Expect(err).To(HaveOccurred())
+		}
+	})
+
+	It("selects names", func() {
+		names := namesForAnnotations()
+		f, err := NewFilter("ann:Doubtful")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names.Apply(f, names.Order(NewGnTagger()))).
+			To(Equal([]int{5, 7, 8, 9}))
+
+		names.Data.Names[0].Odds = 1000
+		names.Data.Names[1].Odds = 0.1
+		f, err = NewFilter("odds:..2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names.Apply(f, []int{1, 0, 2})).To(Equal([]int{1}))
+	})
+})
+
+var _ = Describe("Likelihoods", func() {
+	It("reads likelihoods of older gnfinder versions", func() {
+		names := NamesFromJSON(pathNamesAnnot)
+		ls := names.Likelihoods(0)
+		Expect(len(ls)).To(BeNumerically(">", 3))
+		Expect(ls[0].Feature).To(Equal("uniEnd3"))
+		Expect(ls[0].Value).To(Equal("oda"))
+		Expect(ls[0].LogOdds).To(BeNumerically("~", 1.887, 0.001))
+		var share float64
+		for i, v := range ls {
+			share += v.Share
+			if i > 0 {
+				Expect(v.Share).To(BeNumerically("<=", ls[i-1].Share))
+			}
+		}
+		Expect(share).To(BeNumerically("~", 1, 0.0001))
+	})
+
+	It("creates a table of likelihoods", func() {
+		names := NamesFromJSON(pathNamesAnnot)
+		strs := names.LikelihoodStrings(0)
+		Expect(strs[0]).To(HavePrefix("Feature"))
+		Expect(strs[len(strs)-1]).To(HavePrefix("Score"))
+		names.Data.Names[0].OddsDetails = nil
+		Expect(names.LikelihoodStrings(0)).
+			To(Equal([]string{"No Bayes details for this name"}))
+	})
+})
+
+var _ = Describe("Export", func() {
+	It("writes names into a tab-separated file", func() {
+		names := namesForExpansions()
+		names.ExpandAbbreviations()
+		names.Data.Names[0].Odds = 1000
+		names.Data.Names[0].Annotation = annotation.Species.String()
+		path := filepath.Join(os.TempDir(), "gntagger_export.tsv")
+		defer os.Remove(path)
+		Expect(names.Export(path)).To(Succeed())
+		b, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		Expect(len(lines)).To(Equal(7))
+		Expect(lines[1]).To(Equal("1\tOctopus briareus\t\tSpecies\t\t3.00\t0\t0"))
+		Expect(lines[3]).To(HavePrefix("3\tO. vulgaris\tOctopus vulgaris\t"))
+	})
+})
+
 func makeNames() *Names {
 	gnt := NewGnTagger()
 	gnt.Bayes = true
@@ -153,8 +631,18 @@ func makeNames() *Names {
 	return n
 }
 
+func namesForExpansions() *Names {
+	ns := []string{"Octopus briareus", "Argonauta", "O. vulgaris",
+		"Ocythoe", "O. tuberculata", "A. argo"}
+	o := output.Output{Names: make([]output.Name, len(ns))}
+	for i, v := range ns {
+		o.Names[i] = output.Name{Name: v, Verbatim: v}
+	}
+	return &Names{Data: o}
+}
+
 func namesForAnnotations() *Names {
-	var o gnfinder.Output
+	var o output.Output
 	o.FromJSON(dataNamesAnnot)
 	return &Names{Path: pathNamesAnnot, Data: o}
 }
@@ -3,12 +3,14 @@ module github.com/gnames/gntagger
 require (
 	github.com/abadojack/whatlanggo v1.0.1 // indirect
 	github.com/atotto/clipboard v0.1.2
+	github.com/gnames/bayes v0.1.0
 	github.com/gnames/gnfinder v0.9.1
 	github.com/jroimartin/gocui v0.4.0
 	github.com/json-iterator/go v1.1.7
 	github.com/magiconair/properties v1.8.1 // indirect
 	github.com/mattn/go-runewidth v0.0.5 // indirect
 	github.com/mitchellh/go-homedir v1.1.0
+	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/nsf/termbox-go v0.0.0-20190817171036-93860e161317 // indirect
 	github.com/onsi/ginkgo v1.10.2
 	github.com/onsi/gomega v1.7.0
@@ -0,0 +1,425 @@
+// Package gold compares names found by gnfinder and names confirmed by
+// curators with a gold standard, a hand-annotated list of names with their
+// positions in the text. The gold standard is read from gnfinder's JSON
+// format or from the standoff format of the brat annotation tool.
+package gold
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gnames/gnfinder/output"
+	"github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/annotation"
+	"github.com/gnames/gntagger/train"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Span is a name and its position in the text.
+type Span struct {
+	Name  string
+	Start int
+	End   int
+	// Type is the type of the name, for example gnfinder's "Binomial" or an
+	// entity type of brat.
+	Type string
+}
+
+// FromJSON reads names of a gold standard in gnfinder's JSON format.
+// Names annotated as NotName or Doubtful, for example in names.json of
+// a curated session, are not a part of the gold standard.
+func FromJSON(path string) ([]Span, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var o output.Output
+	if err = jsoniter.Unmarshal(b, &o); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	var res []Span
+	for _, v := range o.Names {
+		if v.Annotation == annotation.NotName.String() ||
+			v.Annotation == annotation.Doubtful.String() {
+			continue
+		}
+		res = append(res, Span{Name: v.Name, Start: v.OffsetStart,
+			End: v.OffsetEnd, Type: v.Type})
+	}
+	return res, nil
+}
+
+// FromBrat reads text-bound annotations of a brat .ann file. If types are
+// given, only entities of these types are read. Fragments of discontinuous
+// entities are joined into one span.
+func FromBrat(path string, types ...string) ([]Span, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	keep := make(map[string]bool)
+	for _, v := range types {
+		keep[v] = true
+	}
+	var res []Span
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "T") {
+			continue
+		}
+		s, err := bratSpan(fields)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", path, line, err)
+		}
+		if len(keep) == 0 || keep[s.Type] {
+			res = append(res, s)
+		}
+	}
+	return res, scanner.Err()
+}
+
+// bratSpan parses fields of a text-bound annotation, for example
+// "T1", "Taxon 0 5;10 16", "Venus mercenaria".
+func bratSpan(fields []string) (Span, error) {
+	var s Span
+	if len(fields) == 3 {
+		s.Name = fields[2]
+	}
+	typeOffsets := strings.SplitN(fields[1], " ", 2)
+	if len(typeOffsets) < 2 {
+		return s, fmt.Errorf("no offsets in '%s'", fields[1])
+	}
+	s.Type = typeOffsets[0]
+	s.Start = -1
+	for _, fragment := range strings.Split(typeOffsets[1], ";") {
+		offsets := strings.Fields(fragment)
+		if len(offsets) != 2 {
+			return s, fmt.Errorf("bad offsets '%s'", fragment)
+		}
+		start, err := strconv.Atoi(offsets[0])
+		if err != nil {
+			return s, err
+		}
+		end, err := strconv.Atoi(offsets[1])
+		if err != nil {
+			return s, err
+		}
+		if s.Start < 0 || start < s.Start {
+			s.Start = start
+		}
+		if end > s.End {
+			s.End = end
+		}
+	}
+	return s, nil
+}
+
+// Found returns names found by gnfinder. Doubtful names are not counted as
+// found.
+func Found(n *gntagger.Names, gnt *gntagger.GnTagger) []Span {
+	var res []Span
+	for i := range n.Data.Names {
+		v := &n.Data.Names[i]
+		if gntagger.IsDoubtful(v, gnt) {
+			continue
+		}
+		res = append(res, Span{Name: v.Name, Start: v.OffsetStart,
+			End: v.OffsetEnd, Type: v.Type})
+	}
+	return res
+}
+
+// Curated returns names confirmed by a curator and the length of the
+// reviewed part of the text. Names marked as Uninomial, Genus or Species
+// are cut to the corresponding number of words.
+func Curated(t *gntagger.Text, n *gntagger.Names) ([]Span, int) {
+	types := make(map[int]string)
+	for _, v := range n.Data.Names {
+		types[v.OffsetStart] = v.Type
+	}
+	c := train.NewCurated(t, n)
+	res := make([]Span, len(c.Names))
+	for i, v := range c.Names {
+		res[i] = Span{Name: v.Name, Start: v.Start, End: v.End,
+			Type: types[v.Start]}
+	}
+	return res, len(c.Text)
+}
+
+// Check separates spans that agree with the text from spans whose name
+// differs from the text at their offsets, or that lie outside of the text.
+// Such spans usually have offsets that refer to another version of the
+// text. Names are compared by words, ignoring case, punctuation and line
+// breaks. Words of discontinuous brat entities may be separated by other
+// words. Spans without a name are not checked.
+func Check(spans []Span, text []rune) (ok, mismatched []Span) {
+	for _, v := range spans {
+		if v.Name == "" || v.Start >= 0 && v.End <= len(text) &&
+			v.Start < v.End && sameWords(v.Name, string(text[v.Start:v.End])) {
+			ok = append(ok, v)
+		} else {
+			mismatched = append(mismatched, v)
+		}
+	}
+	return ok, mismatched
+}
+
+// sameWords returns true if the text starts with the first word of the
+// name, ends with the last one, and contains the rest in the same order.
+func sameWords(name, text string) bool {
+	nw, tw := words(name), words(text)
+	if len(nw) == 0 || len(tw) == 0 || nw[0] != tw[0] ||
+		nw[len(nw)-1] != tw[len(tw)-1] {
+		return false
+	}
+	i := 0
+	for _, w := range tw {
+		if i < len(nw) && w == nw[i] {
+			i++
+		}
+	}
+	return i == len(nw)
+}
+
+// words returns lowercase words of a string. Words hyphenated at the end
+// of a line are joined. Anything but letters separates words, because
+// gnfinder replaces other characters within names.
+func words(s string) []string {
+	s = hyphenation.ReplaceAllString(s, "")
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+var hyphenation = regexp.MustCompile(`-[ \t]*\r?\n\s*`)
+
+// Before returns spans that start before the limit.
+func Before(spans []Span, limit int) []Span {
+	var res []Span
+	for _, v := range spans {
+		if v.Start < limit {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// Outside returns spans that do not overlap with any of the excluded
+// spans, for example names found where the gold standard does not agree
+// with the text.
+func Outside(spans, excluded []Span) []Span {
+	var res []Span
+	for _, v := range spans {
+		keep := true
+		for _, e := range excluded {
+			if overlap(v, e) > 0 {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// Options set how names are matched with the gold standard.
+type Options struct {
+	// Overlap counts names that overlap with gold names as found. Otherwise
+	// only names with the same start and end are found.
+	Overlap bool
+	// MinOverlap is the smallest share of the longer of two names covered
+	// by their intersection for names to be considered overlapping. Zero
+	// means any intersection.
+	MinOverlap float64
+}
+
+// Result of the comparison of names with the gold standard.
+type Result struct {
+	train.Quality
+	// Boundary is the number of names that overlap with gold names but
+	// have different start or end. Without the Overlap option they are
+	// counted both as false positives and false negatives.
+	Boundary int
+	// Types are results for every type of names. True positives, false
+	// negatives and boundary errors are counted by types of gold names,
+	// false positives by types of found names.
+	Types map[string]*Result
+}
+
+type counts struct {
+	tp, fp, fn, boundary int
+}
+
+// Compare matches names with the gold standard.
+func Compare(gold, found []Span, opts Options) *Result {
+	gold, found = sorted(gold), sorted(found)
+	var total counts
+	types := make(map[string]*counts)
+	count := func(typ string, f func(*counts)) {
+		f(&total)
+		if _, ok := types[typ]; !ok {
+			types[typ] = &counts{}
+		}
+		f(types[typ])
+	}
+
+	used := make([]bool, len(found))
+	matched := make([]bool, len(gold))
+	exact := make(map[[2]int]int)
+	for i, v := range found {
+		exact[[2]int{v.Start, v.End}] = i
+	}
+	for i, g := range gold {
+		if j, ok := exact[[2]int{g.Start, g.End}]; ok && !used[j] {
+			used[j], matched[i] = true, true
+			count(g.Type, func(c *counts) { c.tp++ })
+		}
+	}
+
+	maxLen := 0
+	for _, v := range found {
+		if v.End-v.Start > maxLen {
+			maxLen = v.End - v.Start
+		}
+	}
+	for i, g := range gold {
+		if matched[i] {
+			continue
+		}
+		j := overlapping(g, found, used, maxLen, opts.MinOverlap)
+		if j < 0 {
+			count(g.Type, func(c *counts) { c.fn++ })
+			continue
+		}
+		used[j] = true
+		if opts.Overlap {
+			count(g.Type, func(c *counts) { c.tp++; c.boundary++ })
+			continue
+		}
+		count(g.Type, func(c *counts) { c.fn++; c.boundary++ })
+		count(found[j].Type, func(c *counts) { c.fp++ })
+	}
+	for i, v := range found {
+		if !used[i] {
+			count(v.Type, func(c *counts) { c.fp++ })
+		}
+	}
+
+	res := total.result()
+	res.Types = make(map[string]*Result)
+	for k, v := range types {
+		res.Types[k] = v.result()
+	}
+	return res
+}
+
+func (c *counts) result() *Result {
+	return &Result{Quality: train.NewQuality(c.tp, c.fp, c.fn),
+		Boundary: c.boundary}
+}
+
+// overlapping returns the index of an unused found name with the largest
+// intersection with a gold name, or -1 if there is no such name.
+func overlapping(g Span, found []Span, used []bool, maxLen int,
+	minOverlap float64) int {
+	res, best := -1, 0.0
+	end := sort.Search(len(found), func(i int) bool {
+		return found[i].Start >= g.End
+	})
+	for j := end - 1; j >= 0 && found[j].Start+maxLen > g.Start; j-- {
+		if used[j] {
+			continue
+		}
+		share := overlap(g, found[j])
+		if share > 0 && share >= minOverlap && share > best {
+			res, best = j, share
+		}
+	}
+	return res
+}
+
+// overlap returns the share of the longer span covered by the intersection
+// of spans.
+func overlap(a, b Span) float64 {
+	start, end := a.Start, a.End
+	if b.Start > start {
+		start = b.Start
+	}
+	if b.End < end {
+		end = b.End
+	}
+	if end <= start {
+		return 0
+	}
+	longer := a.End - a.Start
+	if b.End-b.Start > longer {
+		longer = b.End - b.Start
+	}
+	return float64(end-start) / float64(longer)
+}
+
+func sorted(spans []Span) []Span {
+	res := append([]Span(nil), spans...)
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Start < res[j].Start
+	})
+	return res
+}
+
+// FormatMismatched returns spans that do not agree with the text as
+// a Markdown table, together with the text at their offsets.
+func FormatMismatched(spans []Span, text []rune) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Mismatched gold names\n\n")
+	fmt.Fprintf(&b, "| Name | Start | End | Text |\n")
+	fmt.Fprintf(&b, "|---|--:|--:|---|\n")
+	for _, v := range spans {
+		var t string
+		if v.Start >= 0 && v.End <= len(text) && v.Start < v.End {
+			t = strings.Join(strings.Fields(string(text[v.Start:v.End])), " ")
+		}
+		fmt.Fprintf(&b, "| %s | %d | %d | %s |\n", v.Name, v.Start, v.End, t)
+	}
+	return b.String()
+}
+
+// Format returns results as a Markdown table, totals first, then types in
+// alphabetical order.
+func Format(title string, r *Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", title)
+	fmt.Fprintf(&b, "| Type | TP | FP | FN | Boundary | Precision | Recall "+
+		"| F1 |\n")
+	fmt.Fprintf(&b, "|---|--:|--:|--:|--:|--:|--:|--:|\n")
+	row := func(name string, r *Result) {
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %d | %.3f | %.3f | %.3f |\n",
+			name, r.TruePos, r.FalsePos, r.FalseNeg, r.Boundary, r.Precision,
+			r.Recall, r.F1)
+	}
+	row("all", r)
+	types := make([]string, 0, len(r.Types))
+	for k := range r.Types {
+		types = append(types, k)
+	}
+	sort.Strings(types)
+	for _, k := range types {
+		name := k
+		if name == "" {
+			name = "(none)"
+		}
+		row(name, r.Types[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package gntagger_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/gnames/gntagger/gold"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Gold", func() {
+	Describe("FromJSON", func() {
+		It("reads names except rejected and doubtful ones", func() {
+			spans, err := FromJSON(pathNamesAnnot)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(spans).To(HaveLen(8))
+			Expect(spans[0].Name).To(Equal("Gastropoda"))
+			Expect(spans[0].Type).To(Equal("Uninomial(nlp)"))
+		})
+	})
+
+	Describe("FromBrat", func() {
+		It("reads text-bound annotations", func() {
+			dir, err := ioutil.TempDir("", "gntagger_gold")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "gold.ann")
+			ann := "T1\tTaxon 0 16\tVenus mercenaria\n" +
+				"#1\tAnnotatorNotes T1\ta clam\n" +
+				"T2\tPlace 20 26\tBoston\n" +
+				"T3\tTaxon 30 37;40 48\tOctopus vulgaris\n"
+			Expect(ioutil.WriteFile(path, []byte(ann), 0644)).To(Succeed())
+
+			spans, err := FromBrat(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(spans).To(HaveLen(3))
+			spans, err = FromBrat(path, "Taxon")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(spans).To(Equal([]Span{
+				{Name: "Venus mercenaria", Start: 0, End: 16, Type: "Taxon"},
+				{Name: "Octopus vulgaris", Start: 30, End: 48, Type: "Taxon"},
+			}))
+
+			Expect(ioutil.WriteFile(path, []byte("T1\tTaxon 0\tVenus\n"),
+				0644)).To(Succeed())
+			_, err = FromBrat(path)
+			Expect(err).To(MatchError(ContainSubstring("gold.ann:1")))
+		})
+	})
+
+	Describe("Check", func() {
+		It("separates names that differ from the text", func() {
+			text := []rune("Venus merce-\nnaria lives with Octopus and " +
+				"Ocypode quadrata.")
+			spans := []Span{
+				{Name: "Venus mercenaria", Start: 0, End: 18},
+				{Name: "Octopus", Start: 30, End: 37},
+				{Name: "Octopus", Start: 29, End: 36},
+				{Name: "Ocypode quadrata", Start: 42, End: 58},
+				{Name: "Ocypode", Start: 50, End: 70},
+			}
+			ok, mismatched := Check(spans, text)
+			Expect(ok).To(Equal([]Span{spans[0], spans[1], spans[3]}))
+			Expect(mismatched).To(Equal([]Span{spans[2], spans[4]}))
+			Expect(FormatMismatched(mismatched, text)).
+				To(ContainSubstring("| Octopus | 29 | 36 | Octopu |"))
+		})
+
+		It("leaves out spans that overlap with excluded ones", func() {
+			spans := []Span{{Start: 0, End: 5}, {Start: 10, End: 15},
+				{Start: 20, End: 25}}
+			Expect(Outside(spans, []Span{{Start: 12, End: 22}})).
+				To(Equal([]Span{{Start: 0, End: 5}}))
+		})
+
+		It("accepts discontinuous brat entities", func() {
+			text := []rune("Octopus (Octopus) vulgaris")
+			ok, _ := Check([]Span{{Name: "Octopus vulgaris", Start: 0,
+				End: 26}}, text)
+			Expect(ok).To(HaveLen(1))
+		})
+	})
+
+	Describe("Compare", func() {
+		gold := []Span{
+			{Start: 0, End: 16, Type: "Binomial"},
+			{Start: 30, End: 46, Type: "Binomial"},
+			{Start: 50, End: 60, Type: "Uninomial"},
+			{Start: 70, End: 80, Type: "Uninomial"},
+		}
+		found := []Span{
+			{Start: 30, End: 37, Type: "Uninomial"},
+			{Start: 0, End: 16, Type: "Binomial"},
+			{Start: 50, End: 60, Type: "Uninomial"},
+			{Start: 90, End: 95, Type: "Uninomial"},
+		}
+
+		It("counts boundary errors as false by default", func() {
+			r := Compare(gold, found, Options{})
+			Expect(r.TruePos).To(Equal(2))
+			Expect(r.FalsePos).To(Equal(2))
+			Expect(r.FalseNeg).To(Equal(2))
+			Expect(r.Boundary).To(Equal(1))
+			Expect(r.Types["Binomial"].FalseNeg).To(Equal(1))
+			Expect(r.Types["Binomial"].Boundary).To(Equal(1))
+			Expect(r.Types["Uninomial"].FalsePos).To(Equal(2))
+			Expect(Format("gnfinder", r)).
+				To(ContainSubstring("| all | 2 | 2 | 2 | 1 | 0.500 | 0.500 |"))
+		})
+
+		It("counts overlapping names as found with the overlap option", func() {
+			r := Compare(gold, found, Options{Overlap: true})
+			Expect(r.TruePos).To(Equal(3))
+			Expect(r.FalsePos).To(Equal(1))
+			Expect(r.FalseNeg).To(Equal(1))
+			Expect(r.Boundary).To(Equal(1))
+
+			r = Compare(gold, found, Options{Overlap: true, MinOverlap: 0.5})
+			Expect(r.TruePos).To(Equal(2))
+			Expect(r.Boundary).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,130 @@
+// Package history keeps decisions made by a curator about name-strings
+// across all curated documents. It allows to use previous experience when
+// a new document is curated.
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gnames/gnfinder/output"
+	"github.com/gnames/gntagger/annotation"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// MinDecisions is the smallest number of previous decisions about
+// a name-string that is enough to pre-annotate the name in a new document.
+const MinDecisions = 2
+
+// Counts keeps how many times each annotation was given to a name-string.
+// Keys are string representations of annotations.
+type Counts map[string]int
+
+// Total returns the number of decisions.
+func (c Counts) Total() int {
+	var res int
+	for _, v := range c {
+		res += v
+	}
+	return res
+}
+
+// Rejected returns the number of "NotName" decisions.
+func (c Counts) Rejected() int {
+	return c[annotation.NotName.String()]
+}
+
+// Accepted returns the number of decisions that recognized the name-string
+// as a name, including decisions about its rank.
+func (c Counts) Accepted() int {
+	return c.Total() - c.Rejected()
+}
+
+// Session contains decisions made during a curation of one document.
+type Session struct {
+	// Path to the names file of the session.
+	Path string `json:"path"`
+	// Names maps name-strings to their annotation counts.
+	Names map[string]Counts `json:"names"`
+}
+
+// History is a user-level collection of curation decisions.
+type History struct {
+	// Path to the history file.
+	Path string `json:"-"`
+	// Sessions maps checksums of texts to decisions made for them.
+	Sessions map[string]*Session `json:"sessions"`
+}
+
+// NewHistory loads history from a file. If the file does not exist yet, it
+// returns an empty history that will be saved to the path.
+func NewHistory(path string) (*History, error) {
+	h := &History{Path: path, Sessions: make(map[string]*Session)}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = jsoniter.Unmarshal(b, h); err != nil {
+		return nil, err
+	}
+	if h.Sessions == nil {
+		h.Sessions = make(map[string]*Session)
+	}
+	return h, nil
+}
+
+// Save writes history to its file.
+func (h *History) Save() error {
+	if err := os.MkdirAll(filepath.Dir(h.Path), 0755); err != nil {
+		return err
+	}
+	b, err := jsoniter.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(h.Path, b, 0644)
+}
+
+// Update replaces decisions of a session with a given checksum by
+// annotations of reviewed names. Names without a decision are ignored.
+func (h *History) Update(checksum string, path string, names []output.Name) {
+	s := &Session{Path: path, Names: make(map[string]Counts)}
+	for _, v := range names {
+		ann, err := annotation.NewAnnotation(v.Annotation)
+		if err != nil || ann.In(annotation.NotAssigned, annotation.Doubtful) {
+			continue
+		}
+		c, ok := s.Names[v.Name]
+		if !ok {
+			c = make(Counts)
+			s.Names[v.Name] = c
+		}
+		c[v.Annotation]++
+	}
+	h.Sessions[checksum] = s
+}
+
+// Past returns decisions about name-strings made in all sessions except
+// the session with the given checksum.
+func (h *History) Past(checksum string) map[string]Counts {
+	res := make(map[string]Counts)
+	for k, s := range h.Sessions {
+		if k == checksum {
+			continue
+		}
+		for name, counts := range s.Names {
+			c, ok := res[name]
+			if !ok {
+				c = make(Counts)
+				res[name] = c
+			}
+			for ann, v := range counts {
+				c[ann] += v
+			}
+		}
+	}
+	return res
+}
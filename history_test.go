@@ -0,0 +1,84 @@
+package gntagger_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gnames/gnfinder/output"
+	"github.com/gnames/gntagger/annotation"
+	. "github.com/gnames/gntagger/history"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History", func() {
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(os.TempDir(), "gntagger_history", "history.json")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(filepath.Dir(path))).To(Succeed())
+	})
+
+	Describe("NewHistory", func() {
+		It("creates an empty history if file does not exist", func() {
+			h, err := NewHistory(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(h.Sessions).To(BeEmpty())
+		})
+	})
+
+	Describe("Update", func() {
+		It("counts decisions of a session, and saves them", func() {
+			h, err := NewHistory(path)
+			Expect(err).ToNot(HaveOccurred())
+			h.Update("abc", "names.json", historyNames(
+				"Venus", annotation.NotName,
+				"Venus", annotation.NotName,
+				"Octopus", annotation.Genus,
+				"Mollusca", annotation.NotAssigned,
+			))
+			h.Update("abc", "names.json", historyNames(
+				"Venus", annotation.NotName,
+				"Octopus", annotation.Genus,
+			))
+			Expect(h.Save()).To(Succeed())
+
+			h, err = NewHistory(path)
+			Expect(err).ToNot(HaveOccurred())
+			s := h.Sessions["abc"]
+			Expect(s.Names).To(HaveLen(2))
+			Expect(s.Names["Venus"].Rejected()).To(Equal(1))
+			Expect(s.Names["Octopus"].Accepted()).To(Equal(1))
+		})
+	})
+
+	Describe("Past", func() {
+		It("sums decisions of other sessions", func() {
+			h, err := NewHistory(path)
+			Expect(err).ToNot(HaveOccurred())
+			h.Update("a", "a.json", historyNames(
+				"Venus", annotation.NotName, "Venus", annotation.Accepted))
+			h.Update("b", "b.json", historyNames("Venus", annotation.NotName))
+			h.Update("c", "c.json", historyNames("Venus", annotation.NotName))
+			past := h.Past("c")
+			Expect(past["Venus"].Total()).To(Equal(3))
+			Expect(past["Venus"].Rejected()).To(Equal(2))
+			Expect(past["Venus"].Accepted()).To(Equal(1))
+		})
+	})
+})
+
+func historyNames(data ...interface{}) []output.Name {
+	var res []output.Name
+	for i := 0; i < len(data); i += 2 {
+		res = append(res, output.Name{
+			Name:       data[i].(string),
+			Annotation: data[i+1].(annotation.Annotation).String(),
+		})
+	}
+	return res
+}
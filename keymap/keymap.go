@@ -0,0 +1,299 @@
+// Package keymap connects keys of the terminal user interface with actions
+// of a curator. It allows to change default keys, for example to vim-like
+// or one-handed layouts, via the configuration file.
+package keymap
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Action is an operation of the terminal user interface that can be bound
+// to keys.
+type Action string
+
+// Actions of the terminal user interface. Values are used as keys of the
+// "keys" section of the configuration file.
+const (
+	Next           Action = "next"
+	Back           Action = "back"
+	No             Action = "no"
+	Yes            Action = "yes"
+	Species        Action = "species"
+	Genus          Action = "genus"
+	Uninomial      Action = "uninomial"
+	Expand         Action = "expand"
+	Propagation    Action = "propagation"
+	Order          Action = "order"
+	Unique         Action = "unique"
+	NextOccurrence Action = "next-occurrence"
+	PrevOccurrence Action = "prev-occurrence"
+	Exception      Action = "exception"
+	Search         Action = "search"
+	NextMatch      Action = "next-match"
+	PrevMatch      Action = "prev-match"
+	Jump           Action = "jump"
+	Filter         Action = "filter"
+	Likelihoods    Action = "likelihoods"
+	StatsView      Action = "stats-view"
+	ScrollUp       Action = "scroll-up"
+	ScrollDown     Action = "scroll-down"
+	PageUp         Action = "page-up"
+	PageDown       Action = "page-down"
+	Snap           Action = "snap"
+	Express        Action = "express"
+	Help           Action = "help"
+	Palette        Action = "palette"
+	Save           Action = "save"
+	Quit           Action = "quit"
+)
+
+// Actions lists all actions in the order they appear in the help line.
+var Actions = []Action{Next, Back, No, Yes, Species, Genus, Uninomial,
+	Expand, Propagation, Order, Unique, NextOccurrence, PrevOccurrence,
+	Exception, Search, NextMatch, PrevMatch, Jump, Filter, Likelihoods,
+	StatsView, ScrollUp, ScrollDown, PageUp, PageDown, Snap, Express, Help,
+	Palette, Save, Quit}
+
+var descriptions = map[Action]string{
+	Next:           "(yes*) next",
+	Back:           "back",
+	No:             "no",
+	Yes:            "yes",
+	Species:        "species",
+	Genus:          "genus",
+	Uninomial:      "uninomial",
+	Expand:         "expand",
+	Propagation:    "propagation",
+	Order:          "order",
+	Unique:         "unique",
+	NextOccurrence: "next occurrence",
+	PrevOccurrence: "prev. occurrence",
+	Exception:      "exception",
+	Search:         "search",
+	NextMatch:      "next match",
+	PrevMatch:      "prev. match",
+	Jump:           "jump",
+	Filter:         "filter",
+	Likelihoods:    "likelihoods",
+	StatsView:      "names/occ. stats",
+	ScrollUp:       "scroll up",
+	ScrollDown:     "scroll down",
+	PageUp:         "page up",
+	PageDown:       "page down",
+	Snap:           "back to name",
+	Express:        "express",
+	Help:           "help",
+	Palette:        "commands",
+	Save:           "save",
+	Quit:           "exit",
+}
+
+// Description returns a short description of an action for the help line.
+func (a Action) Description() string {
+	return descriptions[a]
+}
+
+// In returns true if the action is one of the given actions.
+func (a Action) In(as ...Action) bool {
+	for _, v := range as {
+		if a == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Defaults are keys used for actions that are not set in the configuration.
+var Defaults = map[Action][]string{
+	Next:           {"Right"},
+	Back:           {"Left"},
+	No:             {"Space"},
+	Yes:            {"y"},
+	Species:        {"s"},
+	Genus:          {"g"},
+	Uninomial:      {"u"},
+	Expand:         {"x"},
+	Propagation:    {"p"},
+	Order:          {"o"},
+	Unique:         {"m"},
+	NextOccurrence: {"Down"},
+	PrevOccurrence: {"Up"},
+	Exception:      {"e"},
+	Search:         {"/"},
+	NextMatch:      {"n"},
+	PrevMatch:      {"N"},
+	Jump:           {":"},
+	Filter:         {"f"},
+	Likelihoods:    {"l"},
+	StatsView:      {"v"},
+	ScrollUp:       {"["},
+	ScrollDown:     {"]"},
+	PageUp:         {"PgUp"},
+	PageDown:       {"PgDn"},
+	Snap:           {"c"},
+	Express:        {"F4"},
+	Help:           {"?"},
+	Palette:        {"Ctrl-P"},
+	Save:           {"Ctrl-S"},
+	Quit:           {"Ctrl-C"},
+}
+
+// Key is a key of a keyboard.
+type Key struct {
+	// Name of the key as it is written in the configuration.
+	Name string
+	// Value is a rune or a gocui.Key suitable for gocui.SetKeybinding.
+	Value interface{}
+}
+
+// IsRune returns true if the key types a character.
+func (k Key) IsRune() bool {
+	_, ok := k.Value.(rune)
+	return ok
+}
+
+// Label returns a short representation of the key for the help line.
+func (k Key) Label() string {
+	if l, ok := labels[strings.ToLower(k.Name)]; ok {
+		return l
+	}
+	if strings.HasPrefix(strings.ToLower(k.Name), "ctrl-") {
+		return "^" + strings.ToUpper(k.Name[5:])
+	}
+	return k.Name
+}
+
+var labels = map[string]string{
+	"right": "→",
+	"left":  "←",
+	"up":    "↑",
+	"down":  "↓",
+	"pgup":  "PgUp",
+	"pgdn":  "PgDn",
+}
+
+var namedKeys = map[string]gocui.Key{
+	"space":     gocui.KeySpace,
+	"enter":     gocui.KeyEnter,
+	"tab":       gocui.KeyTab,
+	"backspace": gocui.KeyBackspace2,
+	"delete":    gocui.KeyDelete,
+	"insert":    gocui.KeyInsert,
+	"home":      gocui.KeyHome,
+	"end":       gocui.KeyEnd,
+	"pgup":      gocui.KeyPgup,
+	"pgdn":      gocui.KeyPgdn,
+	"up":        gocui.KeyArrowUp,
+	"down":      gocui.KeyArrowDown,
+	"left":      gocui.KeyArrowLeft,
+	"right":     gocui.KeyArrowRight,
+}
+
+// NewKey parses a key name. A name is either a single character, or one of
+// Space, Enter, Tab, Backspace, Delete, Insert, Home, End, PgUp, PgDn, Up,
+// Down, Left, Right, F1-F12, Ctrl-A - Ctrl-Z. Names are case-insensitive,
+// except single characters.
+func NewKey(name string) (Key, error) {
+	k := Key{Name: name}
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		k.Value = r
+		return k, nil
+	}
+	lower := strings.ToLower(name)
+	if v, ok := namedKeys[lower]; ok {
+		k.Value = v
+		return k, nil
+	}
+	if strings.HasPrefix(lower, "ctrl-") && len(lower) == 6 &&
+		lower[5] >= 'a' && lower[5] <= 'z' {
+		k.Value = gocui.KeyCtrlA + gocui.Key(lower[5]-'a')
+		return k, nil
+	}
+	if strings.HasPrefix(lower, "f") {
+		if n, err := strconv.Atoi(lower[1:]); err == nil && n >= 1 && n <= 12 {
+			k.Value = gocui.KeyF1 - gocui.Key(n-1)
+			return k, nil
+		}
+	}
+	if lower == "true" || lower == "false" {
+		return k, fmt.Errorf("unknown key '%s', YAML reads y, n, yes, no "+
+			"as booleans, put them in quotes", name)
+	}
+	return k, fmt.Errorf("unknown key '%s'", name)
+}
+
+// Keymap binds actions to keys.
+type Keymap map[Action][]Key
+
+// NewKeymap creates a keymap from defaults and keys from the configuration.
+// Keys of an action from the configuration replace its default keys.
+// It returns an error if an action or a key is unknown, or if a key is
+// bound to more than one action.
+func NewKeymap(conf map[string][]string) (Keymap, error) {
+	names := make(map[Action][]string)
+	for a, v := range Defaults {
+		names[a] = v
+	}
+	for a, v := range conf {
+		if _, ok := Defaults[Action(a)]; !ok {
+			if a == "true" || a == "false" {
+				return nil, fmt.Errorf("unknown action '%s', YAML reads yes and "+
+					"no as booleans, put them in quotes", a)
+			}
+			return nil, fmt.Errorf("unknown action '%s' in keys configuration", a)
+		}
+		if len(v) == 0 {
+			return nil, fmt.Errorf("no keys for action '%s'", a)
+		}
+		names[Action(a)] = v
+	}
+
+	km := make(Keymap)
+	used := make(map[interface{}]Action)
+	var conflicts []string
+	for _, a := range Actions {
+		for _, name := range names[a] {
+			k, err := NewKey(name)
+			if err != nil {
+				return nil, fmt.Errorf("action '%s': %s", a, err)
+			}
+			if other, ok := used[k.Value]; ok && other != a {
+				conflicts = append(conflicts,
+					fmt.Sprintf("'%s' is bound to '%s' and '%s'", name, other, a))
+				continue
+			}
+			used[k.Value] = a
+			km[a] = append(km[a], k)
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("conflicting keys: %s", strings.Join(conflicts, "; "))
+	}
+	return km, nil
+}
+
+// Help returns a help line that describes keys of all actions.
+func (km Keymap) Help() string {
+	res := make([]string, 0, len(Actions))
+	for _, a := range Actions {
+		res = append(res, fmt.Sprintf("%s %s", km.Label(a), a.Description()))
+	}
+	return strings.Join(res, ", ")
+}
+
+// Label returns labels of keys of an action separated by '/'.
+func (km Keymap) Label(a Action) string {
+	ls := make([]string, len(km[a]))
+	for i, k := range km[a] {
+		ls[i] = k.Label()
+	}
+	return strings.Join(ls, "/")
+}
@@ -0,0 +1,82 @@
+package gntagger_test
+
+import (
+	. "github.com/gnames/gntagger/keymap"
+	"github.com/jroimartin/gocui"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Keymap", func() {
+	Describe("NewKey", func() {
+		It("parses characters and named keys", func() {
+			k, err := NewKey("y")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(k.Value).To(Equal('y'))
+			Expect(k.IsRune()).To(BeTrue())
+
+			k, err = NewKey("ctrl-s")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(k.Value).To(Equal(gocui.KeyCtrlS))
+			Expect(k.Label()).To(Equal("^S"))
+
+			k, err = NewKey("F4")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(k.Value).To(Equal(gocui.KeyF4))
+			Expect(k.IsRune()).To(BeFalse())
+
+			k, err = NewKey("Left")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(k.Value).To(Equal(gocui.KeyArrowLeft))
+			Expect(k.Label()).To(Equal("←"))
+
+			k, err = NewKey("pgdn")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(k.Value).To(Equal(gocui.KeyPgdn))
+			Expect(k.Label()).To(Equal("PgDn"))
+		})
+
+		It("breaks on unknown keys", func() {
+			for _, v := range []string{"Hyper", "F13", "Ctrl-1", "true"} {
+				_, err := NewKey(v)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+
+	Describe("NewKeymap", func() {
+		It("uses default keys", func() {
+			km, err := NewKeymap(nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(km.Label(Next)).To(Equal("→"))
+			Expect(km.Help()).To(HavePrefix("→ (yes*) next, ← back, Space no"))
+		})
+
+		It("replaces default keys", func() {
+			km, err := NewKeymap(map[string][]string{
+				"next":        {"l", "Right"},
+				"back":        {"h", "Left"},
+				"likelihoods": {"d"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(km.Help()).To(HavePrefix("l/→ (yes*) next, h/← back"))
+		})
+
+		It("finds conflicts and unknown actions", func() {
+			_, err := NewKeymap(map[string][]string{"next": {"y"}})
+			Expect(err).To(MatchError(
+				"conflicting keys: 'y' is bound to 'next' and 'yes'"))
+			_, err = NewKeymap(map[string][]string{"fly": {"z"}})
+			Expect(err).To(HaveOccurred())
+			_, err = NewKeymap(map[string][]string{"genus": {}})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("finds conflicts between aliases of keys", func() {
+			_, err := NewKeymap(map[string][]string{"filter": {"Tab"},
+				"order": {"Ctrl-I"}})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
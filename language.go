@@ -0,0 +1,65 @@
+package gntagger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gnames/gnfinder/lang"
+)
+
+// DetectLanguage is the value of GnTagger.Language that turns on detection
+// of the language of every text.
+const DetectLanguage = "detect"
+
+// Languages returns codes of languages supported by the name-finder.
+func Languages() []string {
+	var res []string
+	for _, v := range lang.SupportedLanguages() {
+		res = append(res, v.String())
+	}
+	return res
+}
+
+// CheckLanguage returns an error if a value of GnTagger.Language is neither
+// a supported language nor DetectLanguage.
+func CheckLanguage(s string) error {
+	if s == DetectLanguage {
+		return nil
+	}
+	if _, err := lang.NewLanguage(s); err != nil {
+		return fmt.Errorf("unknown language '%s', use %s or %s", s,
+			strings.Join(Languages(), ", "), DetectLanguage)
+	}
+	return nil
+}
+
+// textLanguage returns the language used for name-finding in a text, and
+// the code of the language of the text. Texts in unsupported languages are
+// processed with the default language of the name-finder.
+func textLanguage(text []rune, setting string) (lang.Language, string) {
+	if setting != DetectLanguage {
+		if l, err := lang.NewLanguage(setting); err == nil {
+			return l, l.String()
+		}
+	}
+	return lang.DetectLanguage(text)
+}
+
+// Language returns the code of the language of the text, detected or set
+// by a user.
+func (n *Names) Language() string {
+	if n.Data.Meta.LanguageDetected != "" {
+		return n.Data.Meta.LanguageDetected
+	}
+	return n.Data.Meta.Language
+}
+
+// LanguageLabel describes the language of the text, and marks languages not
+// supported by the name-finder.
+func (n *Names) LanguageLabel() string {
+	meta := n.Data.Meta
+	if meta.LanguageDetected == "" || meta.LanguageDetected == meta.Language {
+		return meta.Language
+	}
+	return fmt.Sprintf("%s (unsupported)", meta.LanguageDetected)
+}
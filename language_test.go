@@ -0,0 +1,64 @@
+package gntagger_test
+
+import (
+	. "github.com/gnames/gntagger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Language", func() {
+	german := "Die Gattung Octopus vulgaris lebt im Mittelmeer und ist " +
+		"eine der bekanntesten Arten der Kraken. Sie wurde von Cuvier " +
+		"beschrieben und gehört zu den Kopffüßern, die im Wasser leben und " +
+		"sich von Krebsen ernähren. Auch Sepia officinalis ist häufig.\n"
+	russian := "Однажды в студеную зимнюю пору я из лесу вышел и увидел " +
+		"Octopus vulgaris и Sepia officinalis на берегу моря, где было " +
+		"очень холодно.\n"
+
+	findNames := func(txt string, gnt *GnTagger) *Names {
+		t := NewText([]byte(txt), "", "abcd")
+		t.Process(80)
+		return NewNames(t, gnt)
+	}
+
+	It("checks language settings", func() {
+		Expect(Languages()).To(Equal([]string{"eng", "deu"}))
+		Expect(CheckLanguage("deu")).To(Succeed())
+		Expect(CheckLanguage(DetectLanguage)).To(Succeed())
+		Expect(CheckLanguage("rus")).
+			To(MatchError(ContainSubstring("unknown language 'rus'")))
+	})
+
+	It("detects the language of a text", func() {
+		n := findNames(german, NewGnTagger())
+		Expect(n.Data.Meta.Language).To(Equal("deu"))
+		Expect(n.Data.Meta.DetectLanguage).To(BeTrue())
+		Expect(n.Language()).To(Equal("deu"))
+		Expect(n.LanguageLabel()).To(Equal("deu"))
+		Expect(n.Data.Names[0].Odds).To(BeNumerically(">", 0))
+	})
+
+	It("uses the language set by a user", func() {
+		gnt := NewGnTagger()
+		gnt.Language = "eng"
+		n := findNames(german, gnt)
+		Expect(n.Data.Meta.Language).To(Equal("eng"))
+		Expect(n.Data.Meta.DetectLanguage).To(BeFalse())
+		Expect(n.Language()).To(Equal("eng"))
+	})
+
+	It("uses Bayes for unsupported languages only if it is forced", func() {
+		gnt := NewGnTagger()
+		n := findNames(russian, gnt)
+		Expect(n.Language()).ToNot(Equal("eng"))
+		Expect(n.LanguageLabel()).To(HaveSuffix(" (unsupported)"))
+		Expect(n.Data.Names).ToNot(BeEmpty())
+		for _, v := range n.Data.Names {
+			Expect(v.Odds).To(Equal(0.0))
+		}
+		gnt.Bayes = true
+		n = findNames(russian, gnt)
+		Expect(n.Data.Names[0].Odds).To(BeNumerically(">", 0))
+	})
+})
@@ -0,0 +1,91 @@
+package gntagger
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Likelihood is one of the components from which the Bayes algorithm
+// calculates odds of a name.
+type Likelihood struct {
+	// Feature is the name of a feature, for example "uniEnd3".
+	Feature string
+	// Value is the value of the feature for the name, for example "oda".
+	Value string
+	// Likelihood is the likelihood ratio of the feature value. Prior odds
+	// are included as the "PriorOdds" feature.
+	Likelihood float64
+	// LogOdds is log10 of Likelihood. Log-odds of all components sum up to
+	// the log-odds of the name.
+	LogOdds float64
+	// Share is a fraction of absolute log-odds of all components that
+	// belongs to this component.
+	Share float64
+}
+
+// Likelihoods returns components of the odds of a name with the index i,
+// the most influential first. It returns nil if the name was not scored by
+// the Bayes algorithm.
+func (n *Names) Likelihoods(i int) []Likelihood {
+	var res []Likelihood
+	var total float64
+	for _, features := range n.Data.Names[i].OddsDetails {
+		for f, values := range features {
+			for v, l := range values {
+				lo := math.Log10(l)
+				total += math.Abs(lo)
+				res = append(res, Likelihood{Feature: string(f),
+					Value: string(v), Likelihood: l, LogOdds: lo})
+			}
+		}
+	}
+	for j := range res {
+		if total > 0 {
+			res[j].Share = math.Abs(res[j].LogOdds) / total
+		}
+	}
+	sort.Slice(res, func(a, b int) bool {
+		if res[a].Share != res[b].Share {
+			return res[a].Share > res[b].Share
+		}
+		return res[a].Feature < res[b].Feature
+	})
+	return res
+}
+
+// LikelihoodStrings composes a table of likelihood components of a name with
+// the index i to show in terminal gui.
+func (n *Names) LikelihoodStrings(i int) []string {
+	ls := n.Likelihoods(i)
+	if len(ls) == 0 {
+		return []string{"No Bayes details for this name"}
+	}
+	res := []string{fmt.Sprintf("%-12s %-16s %7s %5s",
+		"Feature", "Value", "log10", "share")}
+	var sum float64
+	for _, l := range ls {
+		color := 32
+		if l.LogOdds < 0 {
+			color = 31
+		}
+		res = append(res, fmt.Sprintf("%-12s %-16s \033[%dm%7.2f\033[0m %4.0f%%",
+			truncate(l.Feature, 12), truncate(l.Value, 16), color, l.LogOdds,
+			l.Share*100))
+		sum += l.LogOdds
+	}
+	res = append(res, fmt.Sprintf("%-29s %7.2f", "Sum", sum))
+	// details of species names keep only one value of features shared by
+	// genus and epithet, so the sum might differ from the score
+	res = append(res, fmt.Sprintf("%-29s %7.2f", "Score",
+		math.Log10(n.Data.Names[i].Odds)))
+	return res
+}
+
+func truncate(s string, size int) string {
+	r := []rune(s)
+	if len(r) <= size {
+		return s
+	}
+	return string(r[:size-1]) + "…"
+}
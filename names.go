@@ -8,8 +8,13 @@ import (
 
 	"github.com/gnames/gnfinder"
 	"github.com/gnames/gnfinder/dict"
+	"github.com/gnames/gnfinder/lang"
 	"github.com/gnames/gnfinder/output"
+	"github.com/gnames/gnfinder/token"
 	"github.com/gnames/gntagger/annotation"
+	"github.com/gnames/gntagger/history"
+	"github.com/gnames/gntagger/refdict"
+	jsoniter "github.com/json-iterator/go"
 )
 
 // Names is an object that keeps output of a name finder and the path where to
@@ -19,6 +24,53 @@ type Names struct {
 	Path string
 	// Data is a gnfinder output
 	Data output.Output
+	// Expansions keeps full forms of abbreviated names. Keys are indices of
+	// names in Data.Names.
+	Expansions map[int]*Expansion
+	// Matches keeps results of verification of names against a reference
+	// dictionary. It is nil if there was no verification.
+	Matches []refdict.Match
+	// refDict is the reference dictionary of the last verification.
+	refDict *refdict.RefDict
+	// Past contains decisions about name-strings made in other documents.
+	Past map[string]history.Counts
+	// Pages contains offsets of the beginnings of pages in the text.
+	Pages []int
+	// Reviewed keeps indices of names reviewed by a curator.
+	Reviewed map[int]bool
+	// Passed keeps indices of names the curator moved past in express mode.
+	// Their annotations come from propagation of decisions, from the history
+	// of decisions or from a reference dictionary, so they are not reviewed.
+	Passed map[int]bool
+	// Decided keeps indices of names annotated by a curator. Names annotated
+	// by propagation of decisions, by the history of decisions or by
+	// a reference dictionary are not there.
+	Decided map[int]bool
+	// changes keeps indices of names with annotations or reviews changed
+	// since the last update of statistics.
+	changes map[int]bool
+}
+
+// namesJSON is a gnfinder output enriched with data created by gntagger.
+// It stays readable by anything that understands gnfinder's JSON format.
+type namesJSON struct {
+	output.Meta `json:"metadata"`
+	Names       []nameJSON `json:"names"`
+	// Reviewed are indices of names reviewed by a curator, Passed are
+	// indices of names passed in express mode, Decided are indices of names
+	// annotated by a curator. They are missing in files created by older
+	// versions of gntagger.
+	Reviewed []int `json:"reviewed"`
+	Passed   []int `json:"passed"`
+	Decided  []int `json:"decided"`
+}
+
+type nameJSON struct {
+	output.Name
+	Expanded *Expansion `json:"expanded,omitempty"`
+	// Likelihoods keep odds details in files created by older versions of
+	// gnfinder.
+	Likelihoods token.OddsDetails `json:"likelihoods,omitempty"`
 }
 
 // NewNames uses a name finder or existing information to return Names structure
@@ -26,12 +78,26 @@ type Names struct {
 func NewNames(text *Text, gnt *GnTagger) *Names {
 	opts := []gnfinder.Option{
 		gnfinder.OptBayesThreshold(gnt.OddsLow),
-		gnfinder.OptDict(dict.LoadDictionary()),
+	}
+	if gnt.Dict != nil {
+		opts = append(opts, gnfinder.OptDict(gnt.Dict))
+	} else {
+		opts = append(opts, gnfinder.OptDict(dict.LoadDictionary()))
+	}
+	if gnt.BayesWeights != nil {
+		opts = append(opts, gnfinder.OptBayesWeights(gnt.BayesWeights))
 	}
 
+	l, code := textLanguage(text.Processed, gnt.Language)
+	// Bayes weights of the default language are used for texts in
+	// unsupported languages only if Bayes is forced.
+	opts = append(opts, gnfinder.OptLanguage(l),
+		gnfinder.OptBayes(gnt.Bayes || l != lang.DefaultLanguage))
 	gnf := gnfinder.NewGNfinder(opts...)
 
 	data := gnf.FindNames([]byte(string(text.Processed)))
+	data.Meta.LanguageDetected = code
+	data.Meta.DetectLanguage = gnt.Language == DetectLanguage
 
 	for i := range data.Names {
 		n := &data.Names[i]
@@ -39,46 +105,287 @@ func NewNames(text *Text, gnt *GnTagger) *Names {
 			n.Annotation = annotation.Doubtful.String()
 		}
 	}
-	return &Names{Data: *data, Path: text.FilePath(NamesFile)}
+	names := &Names{
+		Data:  *data,
+		Path:  text.FilePath(NamesFile),
+		Pages: text.PageStarts(),
+	}
+	names.ExpandAbbreviations()
+	if gnt.History != nil {
+		names.Past = gnt.History.Past(text.Checksum)
+		names.PreAnnotate()
+	}
+	return names
 }
 
 // Save writes current state of names to file
 func (n *Names) Save() error {
-	json := n.Data.ToJSON()
-	return ioutil.WriteFile(n.Path, json, 0644)
+	return ioutil.WriteFile(n.Path, n.ToJSON(), 0644)
 }
 
-// NameStrings composes text to show in terminal gui
-func NameStrings(n *output.Name, current bool, i int,
-	total int) ([]string, error) {
+// ToJSON converts names and their gntagger-specific data to JSON.
+func (n *Names) ToJSON() []byte {
+	nj := namesJSON{Meta: n.Data.Meta, Names: make([]nameJSON, len(n.Data.Names)),
+		Reviewed: make([]int, 0, len(n.Reviewed)),
+		Passed:   make([]int, 0, len(n.Passed)),
+		Decided:  make([]int, 0, len(n.Decided))}
+	for i, v := range n.Data.Names {
+		nj.Names[i] = nameJSON{Name: v, Expanded: n.Expansions[i]}
+		if n.Reviewed[i] {
+			nj.Reviewed = append(nj.Reviewed, i)
+		}
+		if n.Passed[i] {
+			nj.Passed = append(nj.Passed, i)
+		}
+		if n.Decided[i] {
+			nj.Decided = append(nj.Decided, i)
+		}
+	}
+	res, err := jsoniter.MarshalIndent(nj, "", "  ")
+	if err != nil {
+		log.Panic(err)
+	}
+	return res
+}
+
+// NameStrings composes text to show a name with the index i in terminal gui
+func (n *Names) NameStrings(i int, current bool) ([]string, error) {
+	nm := &n.Data.Names[i]
 	name := make([]string, 4)
-	nameString := n.Name
+	nameString := nm.Name
 	if current {
 		nameString = fmt.Sprintf("\033[33;40;1m%s\033[0m", nameString)
 	}
-	name[0] = fmt.Sprintf("    %d/%d", i+1, total)
-	name[1] = n.Type
-	if n.Odds != 0.0 {
-		name[1] = fmt.Sprintf("%s (Score: %0.2f)", name[1], math.Log10(n.Odds))
+	if exp := n.ExpandedName(i); exp != "" {
+		nameString = fmt.Sprintf("%s \033[36m(%s)\033[0m", nameString, exp)
+	}
+	name[0] = fmt.Sprintf("    %d/%d", i+1, len(n.Data.Names))
+	if c, ok := n.Past[nm.Name]; ok {
+		name[0] = fmt.Sprintf("%s  Past: %d/%d acc.", name[0], c.Accepted(),
+			c.Total())
+	}
+	name[1] = nm.Type
+	if nm.Odds != 0.0 {
+		name[1] = fmt.Sprintf("%s (Score: %0.2f)", name[1], math.Log10(nm.Odds))
 	}
 	name[2] = fmt.Sprintf("Name: %s", nameString)
-	ann, err := annotation.NewAnnotation(n.Annotation)
+	ann, err := annotation.NewAnnotation(nm.Annotation)
 	if err != nil {
 		return nil, err
 	}
 	name[3] = ann.Format()
+	if n.Matches != nil {
+		name[3] = fmt.Sprintf("%s %s", name[3], n.Matches[i].Format())
+	}
 	return name, nil
 }
 
+// PreAnnotate uses decisions made in other documents to annotate names that
+// were not reviewed yet. Names that were always rejected become NotName,
+// names that were always accepted become Accepted, and names with conflicting
+// decisions become Doubtful.
+func (n *Names) PreAnnotate() {
+	for i := range n.Data.Names {
+		name := &n.Data.Names[i]
+		ann, err := annotation.NewAnnotation(name.Annotation)
+		if err != nil || !ann.In(annotation.NotAssigned, annotation.Doubtful) {
+			continue
+		}
+		c, ok := n.Past[name.Name]
+		if !ok || c.Total() < history.MinDecisions {
+			continue
+		}
+		switch {
+		case c.Accepted() == 0:
+			name.Annotation = annotation.NotName.String()
+		case c.Rejected() == 0:
+			name.Annotation = annotation.Accepted.String()
+		default:
+			name.Annotation = annotation.Doubtful.String()
+		}
+		n.change(i)
+	}
+}
+
+// Verify matches names against a reference dictionary. Abbreviated names
+// are matched using their expanded form.
+func (n *Names) Verify(rd *refdict.RefDict) {
+	n.refDict = rd
+	n.Matches = make([]refdict.Match, len(n.Data.Names))
+	for i := range n.Data.Names {
+		n.match(i)
+	}
+}
+
+// match verifies a name with the index i against the reference dictionary
+// of the last verification.
+func (n *Names) match(i int) {
+	name := n.Data.Names[i].Name
+	if exp := n.ExpandedName(i); exp != "" {
+		name = exp
+	}
+	n.Matches[i] = n.refDict.Match(name)
+}
+
+// AutoAccept accepts a name with the index i if it was not reviewed yet and
+// it matches exactly a reference dictionary. It returns true if the name is
+// accepted.
+func (n *Names) AutoAccept(i int) bool {
+	if n.Matches == nil || n.Matches[i] != refdict.Exact {
+		return false
+	}
+	name := &n.Data.Names[i]
+	ann, err := annotation.NewAnnotation(name.Annotation)
+	if err != nil || !ann.In(annotation.NotAssigned, annotation.Doubtful) {
+		return false
+	}
+	name.Annotation = annotation.Accepted.String()
+	n.change(i)
+	return true
+}
+
 // NamesFromJSON creates gntagger's name structure from a finder output
 func NamesFromJSON(path string) *Names {
-	o := output.Output{}
+	var nj namesJSON
 	b, err := ioutil.ReadFile(path)
 	if err != nil {
 		log.Panicln(err)
 	}
-	o.FromJSON(b)
-	return &Names{path, o}
+	if err = jsoniter.Unmarshal(b, &nj); err != nil {
+		log.Panicln(err)
+	}
+	names := &Names{
+		Path:       path,
+		Data:       output.Output{Meta: nj.Meta},
+		Expansions: make(map[int]*Expansion),
+	}
+	names.Data.Names = make([]output.Name, len(nj.Names))
+	for i, v := range nj.Names {
+		names.Data.Names[i] = v.Name
+		if len(v.OddsDetails) == 0 && len(v.Likelihoods) > 0 {
+			names.Data.Names[i].OddsDetails = v.Likelihoods
+		}
+		if v.Expanded != nil {
+			names.Expansions[i] = v.Expanded
+		}
+	}
+	for _, i := range nj.Reviewed {
+		names.review(i)
+	}
+	for _, i := range nj.Passed {
+		names.pass(i)
+	}
+	for _, i := range nj.Decided {
+		names.decide(i)
+	}
+	if nj.Reviewed == nil && nj.Decided == nil {
+		// older versions reviewed names only in the order of the text, all
+		// names up to the current name are taken as reviewed, and names with
+		// decisions among them as decided by the curator.
+		for i := 0; i <= nj.Meta.CurrentName && i < len(nj.Names); i++ {
+			names.review(i)
+			if hasDecision(&names.Data.Names[i]) {
+				names.decide(i)
+			}
+		}
+	}
+	names.ExpandAbbreviations()
+	return names
+}
+
+// review records that a name with the index i was reviewed by a curator.
+func (n *Names) review(i int) {
+	if n.Reviewed == nil {
+		n.Reviewed = make(map[int]bool)
+	}
+	if !n.Reviewed[i] {
+		n.Reviewed[i] = true
+		n.change(i)
+	}
+}
+
+// pass records that the curator moved past a name with the index i in
+// express mode.
+func (n *Names) pass(i int) {
+	if n.Passed == nil {
+		n.Passed = make(map[int]bool)
+	}
+	n.Passed[i] = true
+}
+
+// decide records that a name with the index i was annotated by a curator.
+func (n *Names) decide(i int) {
+	n.review(i)
+	if n.Decided == nil {
+		n.Decided = make(map[int]bool)
+	}
+	n.Decided[i] = true
+}
+
+// change records that the annotation or the review of a name with the
+// index i changed.
+func (n *Names) change(i int) {
+	if n.changes == nil {
+		n.changes = make(map[int]bool)
+	}
+	n.changes[i] = true
+}
+
+// takeChanges returns indices of names changed since the previous call.
+func (n *Names) takeChanges() map[int]bool {
+	res := n.changes
+	n.changes = nil
+	return res
+}
+
+// Seen returns true if a name with the index i was reviewed by the curator
+// or passed in express mode.
+func (n *Names) Seen(i int) bool {
+	return n.Reviewed[i] || n.Passed[i]
+}
+
+// SeenEdge returns the index of the furthest reviewed or passed name, or -1
+// if no names were seen.
+func (n *Names) SeenEdge() int {
+	res := -1
+	for _, seen := range []map[int]bool{n.Reviewed, n.Passed} {
+		for i := range seen {
+			if i > res {
+				res = i
+			}
+		}
+	}
+	return res
+}
+
+// ReviewedNames returns names reviewed by a curator in the order of the
+// text.
+func (n *Names) ReviewedNames() []output.Name {
+	res := make([]output.Name, 0, len(n.Reviewed))
+	for i, v := range n.Data.Names {
+		if n.Reviewed[i] {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// DecidedNames returns names annotated by a curator in the order of the
+// text.
+func (n *Names) DecidedNames() []output.Name {
+	res := make([]output.Name, 0, len(n.Decided))
+	for i, v := range n.Data.Names {
+		if n.Decided[i] {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// hasDecision returns true if a name is accepted, rejected or has a rank.
+func hasDecision(n *output.Name) bool {
+	ann, err := annotation.NewAnnotation(n.Annotation)
+	return err == nil && !ann.In(annotation.NotAssigned, annotation.Doubtful)
 }
 
 // GetCurrentName returns currently selected name
@@ -87,9 +394,12 @@ func (n *Names) GetCurrentName() *output.Name {
 }
 
 // UpdateAnnotations takes an annotation updates the current name with it.
-// If needed, it propagates annotations further down the 'unseen' list.
+// If needed, it propagates annotations further down the 'unseen' list
+// according to the propagation policy. Names that were reviewed or passed
+// already keep their annotations. It returns the number of other names affected by the
+// propagation.
 func (n *Names) UpdateAnnotations(newAnnot annotation.Annotation, edge int,
-	gnt *GnTagger) error {
+	gnt *GnTagger) (int, error) {
 	var (
 		err      error
 		oldAnnot annotation.Annotation
@@ -98,40 +408,43 @@ func (n *Names) UpdateAnnotations(newAnnot annotation.Annotation, edge int,
 	name := n.GetCurrentName()
 	oldAnnot, err = annotation.NewAnnotation(name.Annotation)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	name.Annotation = newAnnot.String()
+	n.change(n.Data.Meta.CurrentName)
 
 	notAtEdge := n.Data.Meta.CurrentName < edge-3
 	notAcceptedOrRejected := !newAnnot.In(annotation.NotName, annotation.Accepted)
 
 	if notAtEdge || notAcceptedOrRejected {
-		return nil
+		return 0, nil
 	}
 	return tryToChangeNamesForward(n, oldAnnot, newAnnot, edge, gnt)
 }
 
 func tryToChangeNamesForward(n *Names, oldAnnot annotation.Annotation,
-	newAnnot annotation.Annotation, edge int, gnt *GnTagger) error {
+	newAnnot annotation.Annotation, edge int, gnt *GnTagger) (int, error) {
+	var count int
 	for i := n.Data.Meta.CurrentName + 1; i < len(n.Data.Names); i++ {
 		name := &n.Data.Names[i]
-		sameName := n.GetCurrentName().Name == name.Name
-		if !sameName {
+		if n.Seen(i) || !n.propagates(name, gnt) {
 			continue
 		}
 
+		count++
+		n.change(i)
 		if oldAnnot.In(annotation.NotAssigned, annotation.Doubtful) {
 			name.Annotation = newAnnot.String()
 		} else {
 			nameAnnot, err := annotation.NewAnnotation(name.Annotation)
 			if err != nil {
-				return err
+				return count, err
 			}
 			unmarkNames(name, nameAnnot, gnt)
 		}
 	}
-	return nil
+	return count, nil
 }
 
 func unmarkNames(name *output.Name, a annotation.Annotation, gnt *GnTagger) {
@@ -145,3 +458,24 @@ func unmarkNames(name *output.Name, a annotation.Annotation, gnt *GnTagger) {
 func IsDoubtful(n *output.Name, gnt *GnTagger) bool {
 	return n.Odds != 0 && n.Odds < gnt.OddsHigh
 }
+
+// Reclassify applies gnt.OddsHigh to names without decisions. Such names
+// become Doubtful if their odds are below the limit, and lose the Doubtful
+// annotation otherwise. It returns the number of changed names.
+func (n *Names) Reclassify(gnt *GnTagger) int {
+	var count int
+	for i := range n.Data.Names {
+		name := &n.Data.Names[i]
+		ann := name.Annotation
+		if ann != annotation.NotAssigned.String() &&
+			ann != annotation.Doubtful.String() {
+			continue
+		}
+		unmarkNames(name, annotation.NotAssigned, gnt)
+		if name.Annotation != ann {
+			n.change(i)
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,129 @@
+package gntagger
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gnames/gntagger/annotation"
+)
+
+// ReviewOrder determines the sequence in which names are reviewed.
+type ReviewOrder int
+
+const (
+	// OrderDocument reviews names in the order they appear in the text.
+	OrderDocument ReviewOrder = iota
+	// OrderInformative reviews the most informative names first.
+	OrderInformative
+)
+
+var orderNames = []string{"document", "informative"}
+
+func (o ReviewOrder) String() string {
+	return orderNames[o]
+}
+
+// Next returns the order that follows o.
+func (o ReviewOrder) Next() ReviewOrder {
+	return (o + 1) % ReviewOrder(len(orderNames))
+}
+
+// Order returns indices of names in the sequence of their review.
+func (n *Names) Order(gnt *GnTagger) []int {
+	if gnt.Order == OrderInformative {
+		return n.InformativeOrder(gnt)
+	}
+	res := make([]int, len(n.Data.Names))
+	for i := range res {
+		res[i] = i
+	}
+	return res
+}
+
+// InformativeOrder returns indices of names, so that names which need
+// a curator's attention most go first. Only the first occurrence of each
+// name-string is moved forward:
+//
+// 1. names with conflicting decisions in other documents or in this document;
+//
+// 2. names scored by Bayes algorithm, the ones with odds closest to
+// GnTagger.OddsHigh or GnTagger.OddsLow first;
+//
+// 3. other names.
+//
+// All other occurrences follow in the document order, because decisions
+// about them are usually propagated from the first occurrence.
+func (n *Names) InformativeOrder(gnt *GnTagger) []int {
+	type item struct {
+		idx  int
+		tier int
+		dist float64
+	}
+	conflicts := n.conflicts()
+	seen := make(map[string]struct{})
+	items := make([]item, len(n.Data.Names))
+	for i := range n.Data.Names {
+		name := &n.Data.Names[i]
+		it := item{idx: i, tier: 3}
+		if _, ok := seen[name.Name]; !ok {
+			seen[name.Name] = struct{}{}
+			it.tier = 2
+			if name.Odds != 0 {
+				it.tier = 1
+				it.dist = uncertainty(name.Odds, gnt)
+			}
+			if _, ok := conflicts[name.Name]; ok {
+				it.tier = 0
+			}
+		}
+		items[i] = it
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].tier != items[j].tier {
+			return items[i].tier < items[j].tier
+		}
+		return items[i].dist < items[j].dist
+	})
+	res := make([]int, len(items))
+	for i, v := range items {
+		res[i] = v.idx
+	}
+	return res
+}
+
+// uncertainty is a distance between log-odds of a name and the closest of
+// OddsHigh, OddsLow limits.
+func uncertainty(odds float64, gnt *GnTagger) float64 {
+	lo := math.Log10(odds)
+	high := math.Abs(lo - math.Log10(gnt.OddsHigh))
+	low := math.Abs(lo - math.Log10(gnt.OddsLow))
+	return math.Min(high, low)
+}
+
+// conflicts returns name-strings that were accepted and rejected in other
+// documents, or in this document.
+func (n *Names) conflicts() map[string]struct{} {
+	res := make(map[string]struct{})
+	for k, v := range n.Past {
+		if v.Accepted() > 0 && v.Rejected() > 0 {
+			res[k] = struct{}{}
+		}
+	}
+	accepted := make(map[string]bool)
+	rejected := make(map[string]bool)
+	for _, v := range n.Data.Names {
+		ann, err := annotation.NewAnnotation(v.Annotation)
+		if err != nil || ann.In(annotation.NotAssigned, annotation.Doubtful) {
+			continue
+		}
+		if ann == annotation.NotName {
+			rejected[v.Name] = true
+		} else {
+			accepted[v.Name] = true
+		}
+		if accepted[v.Name] && rejected[v.Name] {
+			res[v.Name] = struct{}{}
+		}
+	}
+	return res
+}
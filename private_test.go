@@ -1,6 +1,8 @@
 package gntagger
 
 import (
+	"github.com/gnames/gnfinder/nlp"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -23,4 +25,18 @@ var _ = Describe("Private", func() {
 			Expect(string(res[0:96])).To(Equal(output))
 		})
 	})
+
+	Describe("copyWeights", func() {
+		It("makes independent copies of Bayes weights", func() {
+			w := nlp.BayesWeights()
+			c := copyWeights(w)
+			Expect(c).To(HaveLen(len(w)))
+			for l, nb := range w {
+				Expect(c[l]).ToNot(BeIdenticalTo(nb))
+				Expect(c[l].Total).To(Equal(nb.Total))
+				Expect(c[l].LabelFreq).To(Equal(nb.LabelFreq))
+				Expect(c[l].FeatureFreq).To(Equal(nb.FeatureFreq))
+			}
+		})
+	})
 })
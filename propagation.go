@@ -0,0 +1,105 @@
+package gntagger
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gnames/gnfinder/output"
+)
+
+// Propagation is a policy that decides which of the following names receive
+// the decision made about the current name.
+type Propagation int
+
+const (
+	// PropagateNone changes only the current name.
+	PropagateNone Propagation = iota
+	// PropagateName changes all following names with the same name-string.
+	PropagateName
+	// PropagateNameType changes all following names with the same name-string
+	// and the same type assigned by the name-finder.
+	PropagateNameType
+	// PropagatePages changes following names with the same name-string that
+	// are located within GnTagger.PropagationPages pages from the current name.
+	PropagatePages
+)
+
+// LinesPerPage is the size of a page for texts without page breaks.
+const LinesPerPage = 50
+
+var propagationNames = []string{"none", "name", "name+type", "pages"}
+
+func (p Propagation) String() string {
+	return propagationNames[p]
+}
+
+// Next returns the policy that follows p. The last policy is followed by
+// the first one.
+func (p Propagation) Next() Propagation {
+	return (p + 1) % Propagation(len(propagationNames))
+}
+
+// Format returns a short description of the policy for the terminal.
+func (p Propagation) Format(gnt *GnTagger) string {
+	if p == PropagatePages {
+		return fmt.Sprintf("%d %s", gnt.PropagationPages, p)
+	}
+	return p.String()
+}
+
+// PageStarts returns offsets of the beginnings of pages in the processed
+// text. Pages are separated by form feeds. If the text has no form feeds,
+// every LinesPerPage lines are considered to be a page.
+func (t *Text) PageStarts() []int {
+	res := []int{0}
+	var formFeed bool
+	for _, r := range t.Processed {
+		if r == '\f' {
+			formFeed = true
+			break
+		}
+	}
+	lines := 0
+	for i, r := range t.Processed {
+		switch {
+		case formFeed && r == '\f':
+			res = append(res, i+1)
+		case !formFeed && r == '\n':
+			lines++
+			if lines%LinesPerPage == 0 {
+				res = append(res, i+1)
+			}
+		}
+	}
+	return res
+}
+
+// page returns the index of a page that contains the given offset.
+func (n *Names) page(offset int) int {
+	return sort.Search(len(n.Pages), func(i int) bool {
+		return n.Pages[i] > offset
+	}) - 1
+}
+
+// propagates decides if a decision about the current name should be
+// propagated to a name.
+func (n *Names) propagates(name *output.Name, gnt *GnTagger) bool {
+	current := n.GetCurrentName()
+	if current.Name != name.Name {
+		return false
+	}
+	switch gnt.Propagation {
+	case PropagateName:
+		return true
+	case PropagateNameType:
+		return current.Type == name.Type
+	case PropagatePages:
+		if n.Pages == nil {
+			return false
+		}
+		dist := n.page(name.OffsetStart) - n.page(current.OffsetStart)
+		return dist <= gnt.PropagationPages
+	default:
+		return false
+	}
+}
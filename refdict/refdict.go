@@ -0,0 +1,250 @@
+// Package refdict provides an offline verification of found names against a
+// local reference list, for example a checklist of a curated group.
+package refdict
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Match describes how a name matched the reference dictionary.
+type Match int
+
+const (
+	// NoMatch means the name was not found in the dictionary.
+	NoMatch Match = iota
+	// Fuzzy means the canonical form or the stemmed canonical form of the name
+	// matched the dictionary.
+	Fuzzy
+	// Exact means the name-string is in the dictionary.
+	Exact
+)
+
+var matchNames = []string{"NoMatch", "Fuzzy", "Exact"}
+
+func (m Match) String() string {
+	return matchNames[m]
+}
+
+// Color returns a terminal color for the match.
+func (m Match) Color() int {
+	switch m {
+	case Exact:
+		return 32 //green
+	case Fuzzy:
+		return 33 //yellow
+	default:
+		return 31 //red
+	}
+}
+
+// Format returns a colored representation of the match for terminal.
+func (m Match) Format() string {
+	return fmt.Sprintf("\033[%d;40;2mRef: %s\033[0m", m.Color(), m.String())
+}
+
+// RefDict is a reference dictionary of scientific names.
+type RefDict struct {
+	// Path to the file with names.
+	Path string
+	// exact contains name-strings as they are given in the file and their
+	// canonical forms.
+	exact map[string]struct{}
+	// stems contains stemmed canonical forms of the names.
+	stems map[string]struct{}
+}
+
+// NewRefDict loads a reference dictionary from a file. The file is either a
+// Darwin Core table (CSV or TSV with a 'scientificName' column) or a plain
+// list of names, one name per line.
+func NewRefDict(path string) (*RefDict, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rd := &RefDict{
+		Path:  path,
+		exact: make(map[string]struct{}),
+		stems: make(map[string]struct{}),
+	}
+
+	r := bufio.NewReader(f)
+	header, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	sep, col := dwcColumn(header)
+	if col == -1 {
+		rd.Add(header)
+		err = rd.readList(r)
+	} else {
+		err = rd.readDwC(r, sep, col)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read reference file %s: %s", path, err)
+	}
+	return rd, nil
+}
+
+// Add adds a name-string to the dictionary.
+func (rd *RefDict) Add(name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+	rd.exact[name] = struct{}{}
+	can := Canonical(name)
+	if can == "" {
+		return
+	}
+	rd.exact[can] = struct{}{}
+	rd.stems[Stem(can)] = struct{}{}
+}
+
+// Len returns the number of distinct entries in the dictionary.
+func (rd *RefDict) Len() int {
+	return len(rd.stems)
+}
+
+// Match finds out if a name-string is in the dictionary.
+func (rd *RefDict) Match(name string) Match {
+	if _, ok := rd.exact[name]; ok {
+		return Exact
+	}
+	can := Canonical(name)
+	if can == "" {
+		return NoMatch
+	}
+	if _, ok := rd.exact[can]; ok {
+		return Fuzzy
+	}
+	if _, ok := rd.stems[Stem(can)]; ok {
+		return Fuzzy
+	}
+	return NoMatch
+}
+
+func (rd *RefDict) readList(r *bufio.Reader) error {
+	for {
+		line, err := r.ReadString('\n')
+		rd.Add(line)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (rd *RefDict) readDwC(r io.Reader, sep rune, col int) error {
+	c := csv.NewReader(r)
+	c.Comma = sep
+	c.FieldsPerRecord = -1
+	c.LazyQuotes = true
+	for {
+		row, err := c.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if col < len(row) {
+			rd.Add(row[col])
+		}
+	}
+}
+
+// dwcColumn detects a Darwin Core header and returns the separator and
+// the index of the scientificName column. The index is -1 for plain lists.
+func dwcColumn(header string) (rune, int) {
+	for _, sep := range []rune{'\t', ','} {
+		for i, v := range strings.Split(strings.TrimSpace(header), string(sep)) {
+			v = strings.ToLower(strings.Trim(v, "\" "))
+			if v == "scientificname" || v == "dwc:scientificname" {
+				return sep, i
+			}
+		}
+	}
+	return 0, -1
+}
+
+var ranks = map[string]struct{}{
+	"var.": {}, "var": {}, "subsp.": {}, "ssp.": {}, "f.": {}, "form": {},
+	"forma": {}, "fma.": {}, "subvar.": {}, "morph.": {}, "ab.": {},
+}
+
+// Canonical returns a canonical form of a name: the genus followed by
+// lower-case epithets. Authors, years, subgenera and rank markers are
+// removed. It returns an empty string if the name does not start with a
+// capitalized word.
+func Canonical(name string) string {
+	words := strings.Fields(name)
+	if len(words) == 0 || !isUninomial(words[0]) {
+		return ""
+	}
+	res := []string{words[0]}
+	for _, w := range words[1:] {
+		if strings.HasPrefix(w, "(") && strings.HasSuffix(w, ")") &&
+			isUninomial(strings.Trim(w, "()")) {
+			continue
+		}
+		if _, ok := ranks[strings.ToLower(w)]; ok {
+			continue
+		}
+		if !isEpithet(w) {
+			break
+		}
+		res = append(res, w)
+	}
+	return strings.Join(res, " ")
+}
+
+// Stem normalizes Latin endings of epithets in a canonical form, so that
+// gender variants like "vulgaris" and "vulgare" produce the same string.
+func Stem(can string) string {
+	words := strings.Fields(can)
+	for i := 1; i < len(words); i++ {
+		words[i] = stemWord(words[i])
+	}
+	return strings.Join(words, " ")
+}
+
+var suffixes = []string{"ensis", "ense", "ius", "ium", "ia", "is", "us",
+	"um", "ae", "a", "e", "i", "os", "on"}
+
+func stemWord(w string) string {
+	for _, s := range suffixes {
+		if len(w)-len(s) >= 3 && strings.HasSuffix(w, s) {
+			return w[:len(w)-len(s)]
+		}
+	}
+	return w
+}
+
+func isUninomial(w string) bool {
+	for i, r := range w {
+		if i == 0 && !unicode.IsUpper(r) {
+			return false
+		}
+		if i > 0 && !unicode.IsLower(r) && r != '-' {
+			return false
+		}
+	}
+	return len(w) > 1
+}
+
+func isEpithet(w string) bool {
+	for _, r := range w {
+		if !unicode.IsLower(r) && r != '-' {
+			return false
+		}
+	}
+	return len(w) > 1
+}
@@ -0,0 +1,55 @@
+package gntagger_test
+
+import (
+	. "github.com/gnames/gntagger/refdict"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RefDict", func() {
+	Describe("NewRefDict", func() {
+		It("loads a Darwin Core table", func() {
+			rd, err := NewRefDict(pathRefDwC)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rd.Len()).To(Equal(3))
+		})
+
+		It("loads a plain list of names", func() {
+			rd, err := NewRefDict(pathRefList)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rd.Len()).To(Equal(3))
+		})
+
+		It("breaks on a missing file", func() {
+			_, err := NewRefDict("./testdata/nofile.txt")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Match", func() {
+		It("matches names exactly and fuzzily", func() {
+			rd, err := NewRefDict(pathRefDwC)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rd.Match("Octopus vulgaris")).To(Equal(Exact))
+			Expect(rd.Match("Mitra mitra")).To(Equal(Exact))
+			Expect(rd.Match("Octopus vulgare")).To(Equal(Fuzzy))
+			Expect(rd.Match("Argonauta argo var. argo")).To(Equal(NoMatch))
+			Expect(rd.Match("Octopus briareus")).To(Equal(NoMatch))
+			rd, err = NewRefDict(pathRefList)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rd.Match("Helix pomatia albina")).To(Equal(Exact))
+			Expect(rd.Match("Helix pomatia f. albina")).To(Equal(Fuzzy))
+		})
+	})
+
+	Describe("Canonical", func() {
+		It("removes authors, subgenera and ranks", func() {
+			Expect(Canonical("Mitra (Mitra) mitra (Linnaeus, 1758)")).
+				To(Equal("Mitra mitra"))
+			Expect(Canonical("Helix pomatia var. albina Mull.")).
+				To(Equal("Helix pomatia albina"))
+			Expect(Canonical("octopus")).To(Equal(""))
+		})
+	})
+})
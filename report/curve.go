@@ -0,0 +1,89 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/annotation"
+)
+
+// Point is the quality of name-finding if names with odds below a threshold
+// were doubtful.
+type Point struct {
+	// LogOdds is log10 of the threshold.
+	LogOdds float64 `json:"log_odds"`
+	Quality
+}
+
+// Odds returns the threshold of the point.
+func (p Point) Odds() float64 {
+	return math.Pow(10, p.LogOdds)
+}
+
+// Curve calculates precision and recall of name-finding in curated sessions
+// for thresholds of log-odds from min to max with a step. Only names
+// reviewed by a curator are counted.
+func Curve(names []*gntagger.Names, min, max, step float64) ([]Point,
+	error) {
+	type reviewed struct {
+		ann  annotation.Annotation
+		odds float64
+	}
+	var rs []reviewed
+	for _, n := range names {
+		for _, v := range n.ReviewedNames() {
+			ann, err := annotation.NewAnnotation(v.Annotation)
+			if err != nil {
+				return nil, err
+			}
+			rs = append(rs, reviewed{ann, v.Odds})
+		}
+	}
+
+	var res []Point
+	// thresholds are counted by steps to avoid accumulation of errors
+	for i := 0; min+float64(i)*step <= max+step/1000; i++ {
+		lo := min + float64(i)*step
+		high := math.Pow(10, lo)
+		var s gntagger.Stats
+		for _, r := range rs {
+			s.Count(r.ann, r.odds != 0 && r.odds < high)
+		}
+		res = append(res, Point{LogOdds: lo, Quality: NewQuality(s)})
+	}
+	return res, nil
+}
+
+// Suggest finds the threshold with the highest recall among thresholds
+// that give at least the target precision. If several thresholds give the
+// same recall, the lowest one is returned. It returns false if no threshold
+// gives the target precision.
+func Suggest(points []Point, precision float32) (Point, bool) {
+	var res Point
+	found := false
+	for _, p := range points {
+		if p.Total == 0 || p.Precision < precision {
+			continue
+		}
+		if !found || p.Recall > res.Recall {
+			res, found = p, true
+		}
+	}
+	return res, found
+}
+
+// FormatCurve returns points of a curve as a Markdown table.
+func FormatCurve(points []Point) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "| Log-odds | Odds | Precision | Recall | F1 "+
+		"| Accepted | Rejected | Modified | Added |\n")
+	fmt.Fprintf(&b, "|--:|--:|--:|--:|--:|--:|--:|--:|--:|\n")
+	for _, p := range points {
+		fmt.Fprintf(&b, "| %.2f | %.4g | %.3f | %.3f | %.3f | %d | %d | %d "+
+			"| %d |\n", p.LogOdds, p.Odds(), p.Precision, p.Recall, p.F1,
+			p.Accepted, p.Rejected, p.Modified, p.Added)
+	}
+	return b.String()
+}
@@ -0,0 +1,277 @@
+// Package report summarizes decisions of curators about names found by
+// gnfinder. Reports contain precision, recall and F1 of name-finding for
+// every curated session and for all of them together, and are written in
+// JSON or Markdown formats to track the quality of name-finding across
+// releases of gnfinder.
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/annotation"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Quality contains counts of decisions about names together with precision,
+// recall and F1 calculated from them.
+type Quality struct {
+	gntagger.Stats
+	Precision float32 `json:"precision"`
+	Recall    float32 `json:"recall"`
+	F1        float32 `json:"f1"`
+}
+
+// NewQuality calculates precision, recall and F1 from statistics of
+// decisions.
+func NewQuality(s gntagger.Stats) Quality {
+	return Quality{Stats: s, Precision: s.Precision(), Recall: s.Recall(),
+		F1: s.F1()}
+}
+
+// Group is the quality of name-finding for a group of names, for example
+// names of the same type.
+type Group struct {
+	Name string `json:"name"`
+	Quality
+}
+
+// Document is the quality of name-finding in one curated session. Only
+// names reviewed by a curator are counted.
+type Document struct {
+	// Path to the session directory, "total" for the aggregate.
+	Path string `json:"path"`
+	// Found is the number of names found by gnfinder.
+	Found int `json:"found"`
+	// Reviewed is the number of names reviewed by a curator.
+	Reviewed int `json:"reviewed"`
+	// OddsHigh is the limit of odds below which names are doubtful. In the
+	// aggregate it is 0 if documents have different limits.
+	OddsHigh float64 `json:"odds_high"`
+	// Occurrences counts every occurrence of names.
+	Occurrences Quality `json:"occurrences"`
+	// Names counts distinct name-strings. In the aggregate they are summed
+	// over documents.
+	Names Quality `json:"names"`
+	// Annotations are numbers of reviewed names with every annotation.
+	Annotations map[string]int `json:"annotations"`
+	// Types are occurrences of names grouped by types assigned by gnfinder.
+	Types []Group `json:"types"`
+	// Odds are occurrences of names grouped by their odds.
+	Odds []Group `json:"odds"`
+}
+
+// Report is an evaluation of name-finding in curated sessions.
+type Report struct {
+	Date      time.Time   `json:"date"`
+	Documents []*Document `json:"documents"`
+	// Total sums all documents.
+	Total *Document `json:"total"`
+}
+
+// oddsBuckets are upper limits of odds buckets, the last bucket has no
+// limit. Names found without odds form a bucket of their own.
+var oddsBuckets = []float64{1, 10, 100, 1000}
+
+// NewDocument evaluates name-finding in a curated session.
+func NewDocument(t *gntagger.Text, n *gntagger.Names,
+	gnt *gntagger.GnTagger) (*Document, error) {
+	d := &Document{Path: t.Path, Found: len(n.Data.Names),
+		OddsHigh: gnt.OddsHigh, Annotations: make(map[string]int)}
+	if d.Found == 0 {
+		return d, nil
+	}
+	tally := gntagger.NewTally()
+	if err := tally.Update(n, gnt); err != nil {
+		return nil, err
+	}
+	d.Occurrences = NewQuality(tally.Occurrences)
+	d.Names = NewQuality(tally.Names)
+
+	reviewed := n.ReviewedNames()
+	d.Reviewed = len(reviewed)
+	types := make(map[string]gntagger.Stats)
+	odds := make(map[string]gntagger.Stats)
+	for i := range reviewed {
+		name := &reviewed[i]
+		ann, err := annotation.NewAnnotation(name.Annotation)
+		if err != nil {
+			return nil, err
+		}
+		d.Annotations[annotationName(ann)]++
+		doubtful := gntagger.IsDoubtful(name, gnt)
+		s := types[name.Type]
+		s.Count(ann, doubtful)
+		types[name.Type] = s
+		bucket := oddsBucket(name.Odds)
+		s = odds[bucket]
+		s.Count(ann, doubtful)
+		odds[bucket] = s
+	}
+	d.Types = groups(types, sortedKeys(types))
+	d.Odds = groups(odds, oddsBucketNames())
+	return d, nil
+}
+
+// New creates a report from evaluated documents.
+func New(docs []*Document) *Report {
+	return &Report{Date: time.Now(), Documents: docs, Total: sum(docs)}
+}
+
+// sum aggregates documents.
+func sum(docs []*Document) *Document {
+	res := &Document{Path: "total", Annotations: make(map[string]int)}
+	var occurrences, names gntagger.Stats
+	types := make(map[string]gntagger.Stats)
+	odds := make(map[string]gntagger.Stats)
+	add := func(m map[string]gntagger.Stats, gs []Group) {
+		for _, g := range gs {
+			s := m[g.Name]
+			s.Sum(g.Stats)
+			m[g.Name] = s
+		}
+	}
+	for i, d := range docs {
+		if i == 0 || d.OddsHigh == res.OddsHigh {
+			res.OddsHigh = d.OddsHigh
+		} else {
+			res.OddsHigh = 0
+		}
+		res.Found += d.Found
+		res.Reviewed += d.Reviewed
+		occurrences.Sum(d.Occurrences.Stats)
+		names.Sum(d.Names.Stats)
+		for k, v := range d.Annotations {
+			res.Annotations[k] += v
+		}
+		add(types, d.Types)
+		add(odds, d.Odds)
+	}
+	res.Occurrences = NewQuality(occurrences)
+	res.Names = NewQuality(names)
+	res.Types = groups(types, sortedKeys(types))
+	res.Odds = groups(odds, oddsBucketNames())
+	return res
+}
+
+// groups converts statistics to groups in the order of keys. Keys without
+// statistics are skipped.
+func groups(m map[string]gntagger.Stats, keys []string) []Group {
+	var res []Group
+	for _, k := range keys {
+		if s, ok := m[k]; ok {
+			res = append(res, Group{Name: k, Quality: NewQuality(s)})
+		}
+	}
+	return res
+}
+
+func sortedKeys(m map[string]gntagger.Stats) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func annotationName(a annotation.Annotation) string {
+	if a == annotation.NotAssigned {
+		return "NotAssigned"
+	}
+	return a.String()
+}
+
+// oddsBucket returns the name of the bucket of odds.
+func oddsBucket(odds float64) string {
+	if odds == 0 {
+		return bucketName(-1)
+	}
+	for i, v := range oddsBuckets {
+		if odds < v {
+			return bucketName(i)
+		}
+	}
+	return bucketName(len(oddsBuckets))
+}
+
+// bucketName returns the name of an odds bucket by its index, -1 is the
+// bucket of names without odds.
+func bucketName(i int) string {
+	switch {
+	case i < 0:
+		return "none"
+	case i == 0:
+		return fmt.Sprintf("<%g", oddsBuckets[0])
+	case i == len(oddsBuckets):
+		return fmt.Sprintf(">=%g", oddsBuckets[i-1])
+	default:
+		return fmt.Sprintf("%g-%g", oddsBuckets[i-1], oddsBuckets[i])
+	}
+}
+
+// oddsBucketNames returns names of odds buckets in increasing order.
+func oddsBucketNames() []string {
+	res := make([]string, 0, len(oddsBuckets)+2)
+	for i := -1; i <= len(oddsBuckets); i++ {
+		res = append(res, bucketName(i))
+	}
+	return res
+}
+
+// JSON returns the report in JSON format.
+func (r *Report) JSON() ([]byte, error) {
+	return jsoniter.MarshalIndent(r, "", "  ")
+}
+
+// Markdown returns the report as Markdown tables.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Name-finding quality\n\n")
+	fmt.Fprintf(&b, "Date: %s\n\n", r.Date.Format(time.RFC3339))
+
+	fmt.Fprintf(&b, "## Documents\n\n")
+	fmt.Fprintf(&b, "| Document | Found | Reviewed | Odds high | Precision "+
+		"| Recall | F1 | Names P/R/F1 |\n")
+	fmt.Fprintf(&b, "|---|--:|--:|--:|--:|--:|--:|--:|\n")
+	docs := append(append([]*Document{}, r.Documents...), r.Total)
+	for _, d := range docs {
+		q, n := d.Occurrences, d.Names
+		oddsHigh := "mixed"
+		if d.OddsHigh != 0 {
+			oddsHigh = fmt.Sprintf("%g", d.OddsHigh)
+		}
+		fmt.Fprintf(&b, "| %s | %d | %d | %s | %.3f | %.3f | %.3f "+
+			"| %.3f/%.3f/%.3f |\n", d.Path, d.Found, d.Reviewed, oddsHigh,
+			q.Precision, q.Recall, q.F1, n.Precision, n.Recall, n.F1)
+	}
+
+	fmt.Fprintf(&b, "\n## Annotations\n\n| Annotation | Names |\n|---|--:|\n")
+	anns := make([]string, 0, len(r.Total.Annotations))
+	for k := range r.Total.Annotations {
+		anns = append(anns, k)
+	}
+	sort.Strings(anns)
+	for _, k := range anns {
+		fmt.Fprintf(&b, "| %s | %d |\n", k, r.Total.Annotations[k])
+	}
+
+	groupsTable(&b, "Types", "Type", r.Total.Types)
+	groupsTable(&b, "Odds", "Odds", r.Total.Odds)
+	return b.String()
+}
+
+func groupsTable(b *strings.Builder, title, column string, gs []Group) {
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	fmt.Fprintf(b, "| %s | Accepted | Rejected | Modified | Added "+
+		"| Precision | Recall | F1 |\n", column)
+	fmt.Fprintf(b, "|---|--:|--:|--:|--:|--:|--:|--:|\n")
+	for _, g := range gs {
+		fmt.Fprintf(b, "| %s | %d | %d | %d | %d | %.3f | %.3f | %.3f |\n",
+			g.Name, g.Accepted, g.Rejected, g.Modified, g.Added,
+			g.Precision, g.Recall, g.F1)
+	}
+}
@@ -0,0 +1,137 @@
+package gntagger_test
+
+import (
+	"strings"
+
+	. "github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/annotation"
+	. "github.com/gnames/gntagger/report"
+	jsoniter "github.com/json-iterator/go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Report", func() {
+	It("evaluates the reviewed part of a document", func() {
+		d, err := NewDocument(&Text{Path: "doc"}, reportNames(), NewGnTagger())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(d.Found).To(Equal(12))
+		Expect(d.Reviewed).To(Equal(8))
+		Expect(d.Occurrences.Stats).To(Equal(Stats{Accepted: 4, Rejected: 1,
+			Modified: 1, Added: 2, Total: 8}))
+		Expect(d.Occurrences.Precision).To(BeNumerically("~", 0.67, 0.01))
+		Expect(d.Names.Total).To(Equal(8))
+		Expect(d.Annotations).To(Equal(map[string]int{"Accepted": 6,
+			"NotName": 1, "Species": 1}))
+
+		var types []string
+		for _, v := range d.Types {
+			types = append(types, v.Name)
+		}
+		Expect(types).To(Equal([]string{"PossibleBinomial", "Uninomial",
+			"Uninomial(nlp)"}))
+		Expect(d.Types[1].Stats).To(Equal(Stats{Accepted: 3, Rejected: 1,
+			Total: 4}))
+		Expect(d.Types[2].Stats).To(Equal(Stats{Accepted: 1, Added: 2,
+			Total: 3}))
+
+		Expect(d.Odds).To(HaveLen(3))
+		Expect(d.Odds[0].Name).To(Equal("10-100"))
+		Expect(d.Odds[0].Added).To(Equal(2))
+		Expect(d.Odds[1].Name).To(Equal("100-1000"))
+		Expect(d.Odds[2].Name).To(Equal(">=1000"))
+		Expect(d.Odds[2].Stats).To(Equal(Stats{Accepted: 3, Rejected: 1,
+			Modified: 1, Total: 5}))
+	})
+
+	It("counts only names reviewed by a curator", func() {
+		names := reportNames()
+		delete(names.Reviewed, 1)
+		d, err := NewDocument(&Text{Path: "doc"}, names, NewGnTagger())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(d.Reviewed).To(Equal(7))
+		Expect(d.Occurrences.Rejected).To(Equal(0))
+		Expect(d.Annotations).To(Equal(map[string]int{"Accepted": 6,
+			"Species": 1}))
+	})
+
+	It("sums documents and writes JSON and Markdown", func() {
+		gnt := NewGnTagger()
+		var docs []*Document
+		for _, path := range []string{"doc1", "doc2"} {
+			d, err := NewDocument(&Text{Path: path}, reportNames(), gnt)
+			Expect(err).ToNot(HaveOccurred())
+			docs = append(docs, d)
+		}
+		r := New(docs)
+		Expect(r.Total.Path).To(Equal("total"))
+		Expect(r.Total.Reviewed).To(Equal(16))
+		Expect(r.Total.Occurrences.Accepted).To(Equal(8))
+		Expect(r.Total.Occurrences.F1).To(Equal(docs[0].Occurrences.F1))
+		Expect(r.Total.Annotations["Accepted"]).To(Equal(12))
+		Expect(r.Total.Types[1].Total).To(Equal(8))
+
+		res, err := r.JSON()
+		Expect(err).ToNot(HaveOccurred())
+		var data map[string]interface{}
+		Expect(jsoniter.Unmarshal(res, &data)).To(Succeed())
+		total := data["total"].(map[string]interface{})
+		occurrences := total["occurrences"].(map[string]interface{})
+		Expect(occurrences["accepted"]).To(BeNumerically("==", 8))
+		Expect(occurrences).To(HaveKey("precision"))
+
+		md := r.Markdown()
+		Expect(md).To(ContainSubstring("| doc2 | 12 | 8 | 100 | 0.667 |"))
+		Expect(md).To(ContainSubstring("| total | 24 | 16 | 100 |"))
+		Expect(md).To(ContainSubstring("| Uninomial(nlp) | 2 | 0 | 0 | 4 |"))
+		Expect(strings.Count(md, "## ")).To(Equal(4))
+	})
+})
+
+var _ = Describe("Curve", func() {
+	It("calculates precision and recall for odds thresholds", func() {
+		names := reportNames()
+		points, err := Curve([]*Names{names, reportNames()}, 1, 3, 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(points).To(HaveLen(3))
+		Expect(points[0].Odds()).To(Equal(10.0))
+		// Octopus and Venus have odds above 10, they are found
+		Expect(points[0].Stats).To(Equal(Stats{Accepted: 12, Rejected: 2,
+			Modified: 2, Total: 16}))
+		Expect(points[1].Stats).To(Equal(Stats{Accepted: 8, Rejected: 2,
+			Modified: 2, Added: 4, Total: 16}))
+		// Gastropoda has odds of 211
+		Expect(points[2].Added).To(Equal(6))
+
+		p, ok := Suggest(points, 0.75)
+		Expect(ok).To(BeTrue())
+		Expect(p.LogOdds).To(Equal(1.0))
+		_, ok = Suggest(points, 0.9)
+		Expect(ok).To(BeFalse())
+		Expect(FormatCurve(points)).
+			To(ContainSubstring("| 2.00 | 100 | 0.667 | 0.667 | 0.667 |"))
+	})
+
+	It("counts only names reviewed by a curator", func() {
+		names := reportNames()
+		delete(names.Reviewed, 1)
+		points, err := Curve([]*Names{names}, 1, 1, 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(points[0].Stats).To(Equal(Stats{Accepted: 6, Modified: 1,
+			Total: 7}))
+	})
+})
+
+// reportNames returns names with decisions about the first 8 names.
+func reportNames() *Names {
+	names := namesForAnnotations()
+	ns := names.Data.Names
+	for i := range ns[:8] {
+		ns[i].Annotation = annotation.Accepted.String()
+	}
+	ns[1].Annotation = annotation.NotName.String()
+	ns[6].Annotation = annotation.Species.String()
+	names.Data.Meta.CurrentName = 7
+	return reviewUpTo(names, 7)
+}
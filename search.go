@@ -0,0 +1,61 @@
+package gntagger
+
+import (
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// Search returns indices of names that match a regular expression, in the
+// order of their appearance in the text. A name matches if its name-string
+// matches, or if a match in the text starts inside the name or between the
+// name and the previous one.
+func (n *Names) Search(t *Text, re *regexp.Regexp) []int {
+	found := make(map[int]struct{})
+	for i, v := range n.Data.Names {
+		if re.MatchString(v.Name) {
+			found[i] = struct{}{}
+		}
+	}
+
+	str := string(t.Processed)
+	var bytePos, runePos int
+	for _, loc := range re.FindAllStringIndex(str, -1) {
+		runePos += utf8.RuneCountInString(str[bytePos:loc[0]])
+		bytePos = loc[0]
+		i := sort.Search(len(n.Data.Names), func(j int) bool {
+			return n.Data.Names[j].OffsetEnd > runePos
+		})
+		if i < len(n.Data.Names) {
+			found[i] = struct{}{}
+		}
+	}
+
+	res := make([]int, 0, len(found))
+	for i := range found {
+		res = append(res, i)
+	}
+	sort.Ints(res)
+	return res
+}
+
+// NextMatch returns the match that follows the current name, or precedes it
+// if forward is false. Search wraps around the ends of the matches. It
+// returns -1 if there are no matches.
+func NextMatch(matches []int, current int, forward bool) int {
+	if len(matches) == 0 {
+		return -1
+	}
+	if forward {
+		i := sort.SearchInts(matches, current+1)
+		if i == len(matches) {
+			i = 0
+		}
+		return matches[i]
+	}
+	i := sort.SearchInts(matches, current) - 1
+	if i < 0 {
+		i = len(matches) - 1
+	}
+	return matches[i]
+}
@@ -0,0 +1,470 @@
+package gntagger
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/gnames/gnfinder/output"
+	"github.com/gnames/gntagger/annotation"
+)
+
+// AutosaveEvery is the number of changes of a session after which the
+// session is saved automatically.
+const AutosaveEvery = 30
+
+// Session keeps the state of a curation of one document: the text, its
+// names, settings and the current position of the review. Reviewed names are
+// kept in Names. A user interface renders a session and changes it with the
+// session's methods.
+type Session struct {
+	// Text is the curated document.
+	Text *Text
+	// Names are names found in the text.
+	Names *Names
+	// GnTagger keeps settings of the session.
+	GnTagger *GnTagger
+	// Propagated is the number of names changed by propagation of the last
+	// decision.
+	Propagated int
+	// UniqueMode is true when distinct name-strings are reviewed instead of
+	// every occurrence of a name.
+	UniqueMode bool
+	// Filter restricts navigation to names that matched it when it was set,
+	// it is nil if all names are shown.
+	Filter *Filter
+	// FilterText is the filter as it was typed by the user.
+	FilterText string
+
+	// order keeps indices of names in the sequence of their review.
+	order []int
+	// orderPos keeps positions of names in the order, -1 for names hidden
+	// by the filter.
+	orderPos []int
+	// uniques keeps distinct name-strings in the unique mode.
+	uniques []Unique
+	// uniqueIdx is the index of the current unique name.
+	uniqueIdx int
+	// occurrence is the index of the shown occurrence of the current unique
+	// name.
+	occurrence int
+	// exceptions keep indices of occurrences of the current unique name that
+	// will receive the opposite decision.
+	exceptions map[int]bool
+	// changes is the number of changes since the last autosave.
+	changes int
+	// tally keeps statistics of reviewed names.
+	tally *Tally
+}
+
+// NewSession creates a session that continues the review of names from
+// their current name.
+func NewSession(text *Text, names *Names, gnt *GnTagger) *Session {
+	s := &Session{
+		Text:       text,
+		Names:      names,
+		GnTagger:   gnt,
+		exceptions: map[int]bool{},
+		tally:      NewTally(),
+	}
+	s.setOrder()
+	return s
+}
+
+// Current returns the index of the current name.
+func (s *Session) Current() int {
+	return s.Names.Data.Meta.CurrentName
+}
+
+// CurrentName returns the current name.
+func (s *Session) CurrentName() *output.Name {
+	return s.Names.GetCurrentName()
+}
+
+// Order returns indices of names in the sequence of their review.
+func (s *Session) Order() []int {
+	return s.order
+}
+
+// Position returns the position of the current name in the order, or the
+// position of the current unique name in the unique mode, and the total
+// number of positions.
+func (s *Session) Position() (int, int) {
+	if s.UniqueMode {
+		return s.uniqueIdx, len(s.uniques)
+	}
+	return s.orderPos[s.Current()], len(s.order)
+}
+
+// NameAt returns the index of a name at a position. In the unique mode it is
+// the first occurrence of the unique name.
+func (s *Session) NameAt(pos int) int {
+	if s.UniqueMode {
+		return s.uniques[pos].Indices[0]
+	}
+	return s.order[pos]
+}
+
+// RowStrings composes text to show a name or a unique name at a position.
+func (s *Session) RowStrings(pos int) ([]string, error) {
+	if s.UniqueMode {
+		return s.Names.UniqueStrings(&s.uniques[pos], pos, len(s.uniques),
+			pos == s.uniqueIdx, s.occurrence, s.exceptions), nil
+	}
+	return s.Names.NameStrings(s.order[pos], pos == s.orderPos[s.Current()])
+}
+
+// Next accepts the current name if it has no decision and moves to the next
+// name. In express mode names that already have decisions are skipped.
+// It does not move from doubtful names, they need a decision. The current
+// name becomes reviewed, and skipped names become passed.
+func (s *Session) Next() error {
+	if s.UniqueMode {
+		s.nextUnique()
+		return nil
+	}
+	name := s.CurrentName()
+	ann, err := annotation.NewAnnotation(name.Annotation)
+	if err != nil {
+		return err
+	}
+
+	if ann == annotation.NotAssigned {
+		if err = s.Annotate(annotation.Accepted); err != nil {
+			return err
+		}
+	}
+
+	from := s.Current()
+	pos := s.orderPos[from]
+	last := len(s.order) - 1
+	step := 1
+	if pos == last || name.Annotation == annotation.Doubtful.String() {
+		step = 0
+	}
+
+	if hasDecision(name) {
+		s.Names.review(from)
+	}
+	pos += step
+	if s.GnTagger.Express && step > 0 {
+		for ; pos < last; pos++ {
+			v := s.Names.Data.Names[s.order[pos]]
+			ann, err := annotation.NewAnnotation(v.Annotation)
+			if err != nil {
+				return fmt.Errorf("unknown annotation %s", v.Annotation)
+			}
+			if ann.In(annotation.NotAssigned, annotation.Doubtful) &&
+				!s.Names.AutoAccept(s.order[pos]) {
+				break
+			}
+			s.Names.pass(s.order[pos])
+		}
+	}
+	s.Names.Data.Meta.CurrentName = s.order[pos]
+	return nil
+}
+
+// nextUnique accepts occurrences of the current unique name that have no
+// decision yet, and moves to the next unique name.
+func (s *Session) nextUnique() {
+	u := &s.uniques[s.uniqueIdx]
+	for _, idx := range u.Indices {
+		v := &s.Names.Data.Names[idx]
+		if v.Annotation != annotation.NotAssigned.String() {
+			continue
+		}
+		v.Annotation = annotation.Accepted.String()
+		s.Names.decide(idx)
+		s.Names.change(idx)
+		if s.exceptions[idx] {
+			v.Annotation = annotation.NotName.String()
+		}
+	}
+	s.reviewUnique(u)
+
+	last := len(s.uniques) - 1
+	if s.uniqueIdx < last && s.Names.IsReviewed(u) {
+		i := s.uniqueIdx + 1
+		if s.GnTagger.Express {
+			for ; i < last; i++ {
+				if !s.Names.IsReviewed(&s.uniques[i]) {
+					break
+				}
+			}
+		}
+		s.setUnique(i)
+	}
+}
+
+// Back moves to the previous name or unique name.
+func (s *Session) Back() {
+	if s.UniqueMode {
+		if s.uniqueIdx > 0 {
+			s.setUnique(s.uniqueIdx - 1)
+		}
+		return
+	}
+	pos := s.orderPos[s.Current()]
+	if pos > 0 {
+		s.Names.Data.Meta.CurrentName = s.order[pos-1]
+	}
+}
+
+// Annotate changes the annotation of the current name and, according to the
+// propagation policy, of the following names. In the unique mode all
+// occurrences of the current unique name are annotated, exceptions receive
+// the opposite decision.
+func (s *Session) Annotate(a annotation.Annotation) error {
+	if s.UniqueMode {
+		u := &s.uniques[s.uniqueIdx]
+		s.Names.AnnotateAll(u, a, s.exceptions)
+		s.Propagated = len(u.Indices) - 1
+		s.exceptions = map[int]bool{}
+		s.reviewUnique(u)
+		return nil
+	}
+
+	edge := s.Names.SeenEdge() + 1
+	if s.GnTagger.Order == OrderInformative {
+		// in informative order every decision is made 'at the edge'
+		edge = s.Current()
+	}
+	var err error
+	s.Propagated, err = s.Names.UpdateAnnotations(a, edge, s.GnTagger)
+	s.Names.decide(s.Current())
+	return err
+}
+
+// reviewUnique marks occurrences of a unique name with decisions as
+// reviewed.
+func (s *Session) reviewUnique(u *Unique) {
+	for _, i := range u.Indices {
+		if hasDecision(&s.Names.Data.Names[i]) {
+			s.Names.review(i)
+		}
+	}
+}
+
+// GoTo makes a name with the given index current. Names between the current
+// name and the name do not become reviewed.
+func (s *Session) GoTo(idx int) {
+	s.Names.Data.Meta.CurrentName = idx
+	if s.UniqueMode {
+		s.locateUnique(idx)
+	} else {
+		s.fitCurrent()
+	}
+}
+
+// JumpTo goes to a name, or a unique name in the unique mode, with the
+// number shown to the user. Numbers out of range go to the first or the last
+// name.
+func (s *Session) JumpTo(num int) {
+	total := len(s.Names.Data.Names)
+	if s.UniqueMode {
+		total = len(s.uniques)
+	}
+	if num > total {
+		num = total
+	}
+	if num < 1 {
+		num = 1
+	}
+	if s.UniqueMode {
+		s.GoTo(s.uniques[num-1].Indices[0])
+		return
+	}
+	s.GoTo(num - 1)
+}
+
+// ToggleUnique switches between reviewing every occurrence of names and
+// reviewing distinct name-strings.
+func (s *Session) ToggleUnique() {
+	s.UniqueMode = !s.UniqueMode
+	if s.UniqueMode {
+		s.setUniques()
+	} else {
+		s.fitCurrent()
+	}
+}
+
+// MoveOccurrence shows another occurrence of the current unique name. The
+// step is 1 for the next occurrence and -1 for the previous one.
+func (s *Session) MoveOccurrence(step int) {
+	if !s.UniqueMode {
+		return
+	}
+	u := &s.uniques[s.uniqueIdx]
+	s.occurrence = (s.occurrence + step + len(u.Indices)) % len(u.Indices)
+	s.Names.Data.Meta.CurrentName = u.Indices[s.occurrence]
+}
+
+// ToggleException marks the shown occurrence of the current unique name as
+// an exception from the decision about the name, or removes the mark.
+func (s *Session) ToggleException() {
+	if !s.UniqueMode {
+		return
+	}
+	idx := s.uniques[s.uniqueIdx].Indices[s.occurrence]
+	if s.exceptions[idx] {
+		delete(s.exceptions, idx)
+	} else {
+		s.exceptions[idx] = true
+	}
+}
+
+// ToggleExpress switches skipping of names that already have decisions.
+func (s *Session) ToggleExpress() {
+	s.GnTagger.Express = !s.GnTagger.Express
+}
+
+// NextPropagation switches to the next propagation policy.
+func (s *Session) NextPropagation() {
+	s.GnTagger.Propagation = s.GnTagger.Propagation.Next()
+	s.Propagated = 0
+}
+
+// NextOrder switches to the next review order.
+func (s *Session) NextOrder() {
+	s.GnTagger.Order = s.GnTagger.Order.Next()
+	s.setOrder()
+}
+
+// SetFilter restricts navigation to names that match a filter. An empty
+// filter removes the filter. It returns false and keeps the old filter if
+// no names match the new one. Names are matched once, so names that stop
+// matching after a decision stay in the order until a filter is set again,
+// and the curator can return to them.
+func (s *Session) SetFilter(f *Filter, input string) bool {
+	ok := true
+	if f.IsEmpty() {
+		s.Filter, s.FilterText = nil, ""
+	} else if len(s.Names.Apply(f, s.Names.Order(s.GnTagger))) == 0 {
+		ok = false
+	} else {
+		s.Filter, s.FilterText = f, input
+	}
+	s.setOrder()
+	if s.UniqueMode {
+		s.setUniques()
+	}
+	return ok
+}
+
+// Search returns indices of names that match a regular expression and pass
+// the filter.
+func (s *Session) Search(re *regexp.Regexp) []int {
+	res := s.Names.Search(s.Text, re)
+	if s.Filter != nil {
+		res = s.Names.Apply(s.Filter, res)
+	}
+	return res
+}
+
+// Stats returns statistics of decisions about reviewed name-strings and
+// about their occurrences.
+func (s *Session) Stats() (names Stats, occurrences Stats, err error) {
+	err = s.tally.Update(s.Names, s.GnTagger)
+	return s.tally.Names, s.tally.Occurrences, err
+}
+
+// Save writes names to disk and updates the history of decisions with
+// names annotated by the curator.
+func (s *Session) Save() error {
+	err := s.Names.Save()
+	if err != nil || s.GnTagger.History == nil {
+		return err
+	}
+	s.GnTagger.History.Update(s.Text.Checksum, s.Names.Path,
+		s.Names.DecidedNames())
+	return s.GnTagger.History.Save()
+}
+
+// Autosave counts changes of the session and saves names after every
+// AutosaveEvery changes. The history of decisions is updated only by Save.
+func (s *Session) Autosave() error {
+	s.changes++
+	if s.changes < AutosaveEvery {
+		return nil
+	}
+	s.changes = 0
+	return s.Names.Save()
+}
+
+// setUnique makes a unique name with index i current and shows its first
+// occurrence.
+func (s *Session) setUnique(i int) {
+	s.uniqueIdx = i
+	s.occurrence = 0
+	s.exceptions = map[int]bool{}
+	s.Names.Data.Meta.CurrentName = s.uniques[i].Indices[0]
+}
+
+// locateUnique makes current the unique name and the occurrence that
+// correspond to a name with the given index.
+func (s *Session) locateUnique(idx int) {
+	s.uniqueIdx, s.occurrence = 0, 0
+	for i := range s.uniques {
+		for j, v := range s.uniques[i].Indices {
+			if v == idx {
+				s.uniqueIdx, s.occurrence = i, j
+			}
+		}
+	}
+	s.exceptions = map[int]bool{}
+}
+
+// setUniques collects unique names that have occurrences matching the
+// filter.
+func (s *Session) setUniques() {
+	s.uniques = s.uniques[:0]
+	for _, u := range s.Names.Uniques() {
+		if s.Filter == nil || len(s.Names.Apply(s.Filter, u.Indices)) > 0 {
+			s.uniques = append(s.uniques, u)
+		}
+	}
+	s.locateUnique(s.Current())
+}
+
+// setOrder arranges names according to the current review order and
+// filter. The filter is removed if no names match it.
+func (s *Session) setOrder() {
+	s.order = s.Names.Order(s.GnTagger)
+	if s.Filter != nil {
+		filtered := s.Names.Apply(s.Filter, s.order)
+		if len(filtered) > 0 {
+			s.order = filtered
+		} else {
+			s.Filter, s.FilterText = nil, ""
+		}
+	}
+	s.orderPos = make([]int, len(s.Names.Data.Names))
+	for i := range s.orderPos {
+		s.orderPos[i] = -1
+	}
+	for pos, idx := range s.order {
+		s.orderPos[idx] = pos
+	}
+	s.fitCurrent()
+}
+
+// fitCurrent moves the current name to the closest following name in the
+// order, if the filter does not show the current name.
+func (s *Session) fitCurrent() {
+	cur := s.Current()
+	if s.orderPos[cur] >= 0 {
+		return
+	}
+	for i := cur + 1; i < len(s.orderPos); i++ {
+		if s.orderPos[i] >= 0 {
+			s.Names.Data.Meta.CurrentName = i
+			return
+		}
+	}
+	for i := cur - 1; i >= 0; i-- {
+		if s.orderPos[i] >= 0 {
+			s.Names.Data.Meta.CurrentName = i
+			return
+		}
+	}
+}
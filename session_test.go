@@ -0,0 +1,214 @@
+package gntagger_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/annotation"
+	"github.com/gnames/gntagger/history"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session", func() {
+	var (
+		s   *Session
+		gnt *GnTagger
+	)
+
+	BeforeEach(func() {
+		gnt = NewGnTagger()
+		gnt.Express = false
+		s = NewSession(&Text{}, namesForAnnotations(), gnt)
+	})
+
+	Describe("Next", func() {
+		It("accepts names without decisions and reviews them", func() {
+			Expect(s.Next()).To(Succeed())
+			ns := s.Names.Data.Names
+			Expect(ns[0].Annotation).To(Equal(annotation.Accepted.String()))
+			Expect(ns[11].Annotation).To(Equal(annotation.Accepted.String()))
+			Expect(s.Propagated).To(Equal(1))
+			Expect(s.Current()).To(Equal(1))
+			Expect(s.Names.Reviewed).To(Equal(map[int]bool{0: true}))
+			Expect(s.Names.Decided).To(Equal(map[int]bool{0: true}))
+		})
+
+		It("stays at doubtful names until they get a decision", func() {
+			for i := 0; i < 5; i++ {
+				Expect(s.Next()).To(Succeed())
+			}
+			Expect(s.CurrentName().Name).To(Equal("Octopus"))
+			Expect(s.Next()).To(Succeed())
+			Expect(s.Current()).To(Equal(5))
+			Expect(s.Annotate(annotation.Genus)).To(Succeed())
+			Expect(s.Next()).To(Succeed())
+			Expect(s.Current()).To(Equal(6))
+		})
+
+		It("skips names with decisions in express mode", func() {
+			gnt.Express = true
+			for i := 1; i < 5; i++ {
+				s.Names.Data.Names[i].Annotation = annotation.NotName.String()
+			}
+			Expect(s.Next()).To(Succeed())
+			Expect(s.Current()).To(Equal(5))
+			Expect(s.Names.Reviewed).To(Equal(map[int]bool{0: true}))
+			Expect(s.Names.Passed).To(HaveLen(4))
+			Expect(s.Names.Decided).To(HaveLen(1))
+			// passed names are not counted in statistics
+			_, occurrences, err := s.Stats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(occurrences.Total).To(Equal(1))
+		})
+
+		It("does not review names skipped by a jump", func() {
+			s.GoTo(10)
+			Expect(s.Names.Reviewed).To(BeEmpty())
+			Expect(s.Next()).To(Succeed())
+			Expect(s.Current()).To(Equal(11))
+			Expect(s.Names.Reviewed).To(Equal(map[int]bool{10: true}))
+			s.Back()
+			s.Back()
+			Expect(s.Current()).To(Equal(9))
+		})
+	})
+
+	Describe("Annotate", func() {
+		It("keeps decisions about reviewed names in informative order", func() {
+			s.NextOrder()
+			s.GoTo(8)
+			Expect(s.Annotate(annotation.NotName)).To(Succeed())
+			Expect(s.Next()).To(Succeed())
+			s.GoTo(7)
+			Expect(s.Annotate(annotation.Accepted)).To(Succeed())
+			ns := s.Names.Data.Names
+			Expect(ns[7].Annotation).To(Equal(annotation.Accepted.String()))
+			Expect(ns[8].Annotation).To(Equal(annotation.NotName.String()))
+			// the unreviewed name follows the last decision
+			Expect(ns[9].Annotation).To(Equal(annotation.Accepted.String()))
+			Expect(s.Names.Reviewed).To(Equal(map[int]bool{7: true, 8: true}))
+		})
+	})
+
+	Describe("JumpTo", func() {
+		It("goes to a name by its number", func() {
+			s.JumpTo(3)
+			Expect(s.Current()).To(Equal(2))
+			s.JumpTo(100)
+			Expect(s.Current()).To(Equal(11))
+			s.JumpTo(0)
+			Expect(s.Current()).To(Equal(0))
+		})
+	})
+
+	Describe("UniqueMode", func() {
+		It("annotates all occurrences of a name-string", func() {
+			s.ToggleUnique()
+			pos, total := s.Position()
+			Expect(pos).To(Equal(0))
+			Expect(total).To(Equal(9))
+			s.MoveOccurrence(1)
+			Expect(s.Current()).To(Equal(11))
+			s.ToggleException()
+			Expect(s.Annotate(annotation.NotName)).To(Succeed())
+			ns := s.Names.Data.Names
+			Expect(ns[0].Annotation).To(Equal(annotation.NotName.String()))
+			Expect(ns[11].Annotation).To(Equal(annotation.Accepted.String()))
+			Expect(s.Names.Reviewed).To(Equal(map[int]bool{0: true, 11: true}))
+			Expect(s.Next()).To(Succeed())
+			Expect(s.CurrentName().Name).To(Equal("Amphineura"))
+		})
+	})
+
+	Describe("SetFilter", func() {
+		It("restricts navigation to matching names", func() {
+			f, err := NewFilter("ann:Doubtful")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.SetFilter(f, "ann:Doubtful")).To(BeTrue())
+			Expect(s.Order()).To(Equal([]int{5, 7, 8, 9}))
+			Expect(s.Current()).To(Equal(5))
+			Expect(s.NameAt(1)).To(Equal(7))
+
+			f, err = NewFilter("type:Trinomial")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.SetFilter(f, "type:Trinomial")).To(BeFalse())
+			Expect(s.FilterText).To(Equal("ann:Doubtful"))
+
+			f, err = NewFilter("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.SetFilter(f, "")).To(BeTrue())
+			Expect(s.Filter).To(BeNil())
+			Expect(len(s.Order())).To(Equal(12))
+		})
+
+		It("keeps names that stop matching after a decision", func() {
+			f, err := NewFilter("ann:Doubtful")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.SetFilter(f, "ann:Doubtful")).To(BeTrue())
+			Expect(s.Annotate(annotation.Genus)).To(Succeed())
+			Expect(s.Next()).To(Succeed())
+			Expect(s.Current()).To(Equal(7))
+			s.Back()
+			Expect(s.Current()).To(Equal(5))
+			Expect(s.Order()).To(Equal([]int{5, 7, 8, 9}))
+
+			Expect(s.SetFilter(f, "ann:Doubtful")).To(BeTrue())
+			Expect(s.Order()).To(Equal([]int{7, 8, 9}))
+			Expect(s.Current()).To(Equal(7))
+		})
+	})
+
+	Describe("Save", func() {
+		It("updates history only with decisions of the curator", func() {
+			dir, err := ioutil.TempDir("", "gntagger_session")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+			gnt.History, err = history.NewHistory(filepath.Join(dir,
+				"history.json"))
+			Expect(err).ToNot(HaveOccurred())
+			s.Names.Path = filepath.Join(dir, "names.json")
+			s.Names.Data.Names[1].Annotation = annotation.NotName.String()
+
+			Expect(s.Next()).To(Succeed())
+			Expect(s.Next()).To(Succeed())
+			Expect(s.Names.Data.Names[11].Annotation).
+				To(Equal(annotation.Accepted.String()))
+			for i := 1; i < AutosaveEvery; i++ {
+				Expect(s.Autosave()).To(Succeed())
+			}
+			Expect(s.Autosave()).To(Succeed())
+			_, err = os.Stat(gnt.History.Path)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+
+			Expect(s.Save()).To(Succeed())
+			h, err := history.NewHistory(gnt.History.Path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(h.Sessions[""].Names).To(HaveLen(1))
+			Expect(h.Sessions[""].Names).To(HaveKey(s.Names.Data.Names[0].Name))
+			Expect(h.Sessions[""].Names[s.Names.Data.Names[0].Name].Total()).
+				To(Equal(1))
+
+			n := NamesFromJSON(s.Names.Path)
+			Expect(n.Decided).To(Equal(map[int]bool{0: true}))
+		})
+	})
+
+	Describe("Autosave", func() {
+		It("saves names after a number of changes", func() {
+			s.Names.Path = filepath.Join(os.TempDir(), "gntagger_session.json")
+			defer os.Remove(s.Names.Path)
+			for i := 1; i < AutosaveEvery; i++ {
+				Expect(s.Autosave()).To(Succeed())
+			}
+			_, err := os.Stat(s.Names.Path)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+			Expect(s.Autosave()).To(Succeed())
+			_, err = os.Stat(s.Names.Path)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,307 @@
+package gntagger
+
+import (
+	"math"
+
+	"github.com/gnames/gntagger/annotation"
+)
+
+// outcome is a result of the review of a name found by the name-finder.
+type outcome int
+
+const (
+	// notCounted are names without decisions, and doubtful names that are
+	// rejected, the name-finder was right to doubt them.
+	notCounted outcome = iota
+	accepted
+	rejected
+	modified
+	// added are doubtful names that are accepted or modified, the
+	// name-finder missed them.
+	added
+)
+
+// occurrenceOutcome classifies an occurrence of a name by its annotation.
+func occurrenceOutcome(ann annotation.Annotation, doubtful bool) outcome {
+	var res outcome
+	switch ann {
+	case annotation.Accepted:
+		res = accepted
+	case annotation.NotName:
+		res = rejected
+	case annotation.Uninomial, annotation.Genus, annotation.Species:
+		res = modified
+	default:
+		return notCounted
+	}
+	return adjustDoubtful(res, doubtful)
+}
+
+// adjustDoubtful changes the outcome of a doubtful name.
+func adjustDoubtful(o outcome, doubtful bool) outcome {
+	if !doubtful {
+		return o
+	}
+	if o == rejected {
+		return notCounted
+	}
+	return added
+}
+
+// WordState keeps decisions about occurrences of a name-string. It allows us
+// to collect statistics about the quality of the name-finding algorithms.
+type WordState struct {
+	accepted int
+	rejected int
+	modified int
+	doubtful int
+}
+
+// add counts (sign is 1) or uncounts (sign is -1) an occurrence of
+// a name-string with the given annotation.
+func (ws *WordState) add(ann annotation.Annotation, doubtful bool, sign int) {
+	switch ann {
+	case annotation.NotName:
+		ws.rejected += sign
+	case annotation.Accepted:
+		ws.accepted += sign
+	case annotation.Uninomial, annotation.Genus, annotation.Species:
+		ws.modified += sign
+	}
+	if doubtful {
+		ws.doubtful += sign
+	}
+}
+
+// outcome classifies a name-string by the most frequent decision about its
+// occurrences. If decisions are mixed in equal numbers, rejection wins over
+// modification, and modification wins over acceptance. A name-string is
+// doubtful if any of its occurrences is doubtful.
+func (ws *WordState) outcome() outcome {
+	res, max := notCounted, 0
+	for _, v := range []struct {
+		o     outcome
+		count int
+	}{{rejected, ws.rejected}, {modified, ws.modified},
+		{accepted, ws.accepted}} {
+		if v.count > max {
+			res, max = v.o, v.count
+		}
+	}
+	if res == notCounted {
+		return res
+	}
+	return adjustDoubtful(res, ws.doubtful > 0)
+}
+
+// Stats is a collection of fields needed for calculating statistics.
+// Names that the name-finder marked as doubtful and the curator accepted or
+// modified are counted as added, in other words missed by the name-finder.
+type Stats struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+	Modified int `json:"modified"`
+	Added    int `json:"added"`
+	Total    int `json:"total"`
+}
+
+// Count adds an occurrence of a name with the given annotation. Names
+// without decisions are not counted.
+func (s *Stats) Count(ann annotation.Annotation, doubtful bool) {
+	s.add(occurrenceOutcome(ann, doubtful), 1)
+}
+
+// Sum adds counts of other statistics.
+func (s *Stats) Sum(other Stats) {
+	s.Accepted += other.Accepted
+	s.Rejected += other.Rejected
+	s.Modified += other.Modified
+	s.Added += other.Added
+	s.Total += other.Total
+}
+
+// add adds (sign is 1) or removes (sign is -1) an outcome.
+func (s *Stats) add(o outcome, sign int) {
+	switch o {
+	case accepted:
+		s.Accepted += sign
+	case rejected:
+		s.Rejected += sign
+	case modified:
+		s.Modified += sign
+	case added:
+		s.Added += sign
+	default:
+		return
+	}
+	s.Total += sign
+}
+
+// Precision is the fraction of accepted names among names found by the
+// name-finder and reviewed by the curator. It is 0 if there are no such
+// names.
+func (s *Stats) Precision() float32 {
+	tpos := float32(s.Accepted)
+	fpos := float32(s.Rejected + s.Modified)
+	if tpos+fpos == 0 {
+		return 0
+	}
+	return tpos / (tpos + fpos)
+}
+
+// Recall is the fraction of accepted names among all correct names,
+// including names the name-finder was not sure about. It is 0 if there are
+// no such names.
+func (s *Stats) Recall() float32 {
+	tpos := float32(s.Accepted)
+	fneg := float32(s.Added)
+	if tpos+fneg == 0 {
+		return 0
+	}
+	return tpos / (tpos + fneg)
+}
+
+// F1 is the harmonic mean of precision and recall.
+func (s *Stats) F1() float32 {
+	p, r := s.Precision(), s.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+// Percentages returns shares of accepted, rejected, modified and added
+// names in percents. They are rounded so, that their sum is 100.
+func (s *Stats) Percentages() (acc, rej, mod, add int) {
+	if s.Total == 0 {
+		return 0, 0, 0, 0
+	}
+
+	rates := []float32{
+		float32(s.Accepted) / float32(s.Total),
+		float32(s.Rejected) / float32(s.Total),
+		float32(s.Modified) / float32(s.Total),
+		float32(s.Added) / float32(s.Total),
+	}
+
+	percent := func(rate float32) int { return int(rate * 100) }
+
+	percents := make([]int, len(rates))
+	totalPercent := 0
+	for i, r := range rates {
+		percents[i] = percent(r)
+		totalPercent += percents[i]
+	}
+
+	for ; totalPercent < 100; totalPercent++ {
+		maxID := -1
+		for i := range percents {
+			if percent(rates[i])-percents[i] < 0 {
+				continue
+			}
+			if maxID == -1 {
+				maxID = i
+				continue
+			}
+			rateIf64 := float64(rates[i])
+			rateMaxf64 := float64(rates[maxID])
+			if (rateIf64 - math.Floor(rateIf64)) >
+				(rateMaxf64 - math.Floor(rateMaxf64)) {
+				maxID = i
+			}
+		}
+		percents[maxID]++
+	}
+	return percents[0], percents[1], percents[2], percents[3]
+}
+
+// Tally keeps statistics of reviewed names up to date. It remembers
+// annotations of names it counted, and recounts only names with changed
+// annotations, so updates stay fast in long documents.
+type Tally struct {
+	// Occurrences counts every occurrence of reviewed names.
+	Occurrences Stats
+	// Names counts distinct reviewed name-strings.
+	Names Stats
+	words map[string]*WordState
+	// annots are annotations of counted names by their indices.
+	annots map[int]annotation.Annotation
+	// names are the counted names.
+	names *Names
+	// oddsHigh is the limit of doubtful names used for counting.
+	oddsHigh float64
+}
+
+// NewTally creates an empty Tally.
+func NewTally() *Tally {
+	return &Tally{words: make(map[string]*WordState),
+		annots: make(map[int]annotation.Annotation)}
+}
+
+// Update counts names reviewed by a curator. All names are counted by the
+// first update, and when names or the limit of doubtful names change. Later
+// updates recount only names changed by Names methods since the previous
+// update.
+func (t *Tally) Update(names *Names, gnt *GnTagger) error {
+	changes := names.takeChanges()
+	if names == t.names && gnt.OddsHigh == t.oddsHigh {
+		for i := range changes {
+			if err := t.update(names, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	*t = *NewTally()
+	t.names, t.oddsHigh = names, gnt.OddsHigh
+	for i := range names.Data.Names {
+		if err := t.update(names, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// update recounts a name with the index i if it was reviewed or counted,
+// and its annotation changed.
+func (t *Tally) update(names *Names, i int) error {
+	old, counted := t.annots[i]
+	if !names.Reviewed[i] {
+		if counted {
+			t.count(i, names, old, -1)
+			delete(t.annots, i)
+		}
+		return nil
+	}
+	ann, err := annotation.NewAnnotation(names.Data.Names[i].Annotation)
+	if err != nil {
+		return err
+	}
+	if counted {
+		if old == ann {
+			return nil
+		}
+		t.count(i, names, old, -1)
+	}
+	t.annots[i] = ann
+	t.count(i, names, ann, 1)
+	return nil
+}
+
+// count adds or removes an occurrence of a name with the index i and
+// updates the contribution of its name-string to statistics.
+func (t *Tally) count(i int, names *Names, ann annotation.Annotation,
+	sign int) {
+	name := &names.Data.Names[i]
+	doubtful := name.Odds != 0.0 && name.Odds < t.oddsHigh
+	t.Occurrences.add(occurrenceOutcome(ann, doubtful), sign)
+
+	ws, ok := t.words[name.Name]
+	if !ok {
+		ws = &WordState{}
+		t.words[name.Name] = ws
+	}
+	t.Names.add(ws.outcome(), -1)
+	ws.add(ann, doubtful, sign)
+	t.Names.add(ws.outcome(), 1)
+}
@@ -0,0 +1,124 @@
+package gntagger_test
+
+import (
+	. "github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/annotation"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tally", func() {
+	It("counts decisions about name-strings and occurrences", func() {
+		names := namesForAnnotations()
+		gnt := NewGnTagger()
+		ns := names.Data.Names
+		for i := range ns {
+			ns[i].Annotation = annotation.Accepted.String()
+		}
+		ns[1].Annotation = annotation.NotName.String()
+		ns[6].Annotation = annotation.Species.String()
+
+		t := NewTally()
+		Expect(t.Update(reviewUpTo(names, 8), gnt)).To(Succeed())
+		// Octopus and Venus are doubtful and accepted, so they are added
+		Expect(t.Names).To(Equal(Stats{Accepted: 4, Rejected: 1, Modified: 1,
+			Added: 2, Total: 8}))
+		Expect(t.Occurrences).To(Equal(Stats{Accepted: 4, Rejected: 1,
+			Modified: 1, Added: 3, Total: 9}))
+		Expect(t.Names.Precision()).To(BeNumerically("~", 0.67, 0.01))
+		Expect(t.Names.Recall()).To(BeNumerically("~", 0.67, 0.01))
+		Expect(t.Names.F1()).To(BeNumerically("~", 0.67, 0.01))
+		acc, rej, mod, add := t.Names.Percentages()
+		Expect(acc + rej + mod + add).To(Equal(100))
+		Expect(NewTally().Names.F1()).To(Equal(float32(0)))
+	})
+
+	It("classifies name-strings with mixed decisions by majority", func() {
+		names := namesForAnnotations()
+		gnt := NewGnTagger()
+		ns := names.Data.Names
+		ns[0].Annotation = annotation.Accepted.String()
+		ns[11].Annotation = annotation.NotName.String()
+		t := NewTally()
+		Expect(t.Update(reviewUpTo(names, 11), gnt)).To(Succeed())
+		// a tie goes to rejection
+		Expect(t.Names).To(Equal(Stats{Rejected: 1, Total: 1}))
+		Expect(t.Occurrences).To(Equal(Stats{Accepted: 1, Rejected: 1,
+			Total: 2}))
+
+		// Venus is doubtful, rejected Venus is not counted
+		ns[7].Annotation = annotation.Genus.String()
+		ns[8].Annotation = annotation.NotName.String()
+		ns[9].Annotation = annotation.NotName.String()
+		t = NewTally()
+		Expect(t.Update(names, gnt)).To(Succeed())
+		Expect(t.Names).To(Equal(Stats{Rejected: 1, Total: 1}))
+		ns[9].Annotation = annotation.Genus.String()
+		t = NewTally()
+		Expect(t.Update(names, gnt)).To(Succeed())
+		Expect(t.Names).To(Equal(Stats{Rejected: 1, Added: 1, Total: 2}))
+		Expect(t.Occurrences.Added).To(Equal(2))
+	})
+
+	It("updates statistics incrementally", func() {
+		gnt := NewGnTagger()
+		gnt.Express = false
+		s := NewSession(&Text{}, namesForAnnotations(), gnt)
+		annotate := func(a annotation.Annotation) func() error {
+			return func() error { return s.Annotate(a) }
+		}
+		steps := []func() error{
+			s.Next,
+			annotate(annotation.NotName),
+			s.Next,
+			s.Next,
+			func() error { s.GoTo(7); return s.Annotate(annotation.Genus) },
+			s.Next,
+			func() error { s.Back(); s.Back(); return nil },
+			annotate(annotation.Accepted),
+			func() error { s.ToggleUnique(); return nil },
+			annotate(annotation.NotName),
+			s.Next,
+		}
+		check := func() (Stats, Stats) {
+			names, occurrences, err := s.Stats()
+			Expect(err).ToNot(HaveOccurred())
+			fresh := NewTally()
+			Expect(fresh.Update(s.Names, gnt)).To(Succeed())
+			Expect(names).To(Equal(fresh.Names))
+			Expect(occurrences).To(Equal(fresh.Occurrences))
+			return names, occurrences
+		}
+		for _, step := range steps {
+			Expect(step()).To(Succeed())
+			check()
+		}
+		names, occurrences := check()
+		Expect(occurrences.Total).To(BeNumerically(">", 3))
+
+		// names changed outside of Names methods are not recounted
+		ns := s.Names.Data.Names
+		for i := range ns {
+			ns[i].Annotation = annotation.NotName.String()
+		}
+		n, o, err := s.Stats()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(names))
+		Expect(o).To(Equal(occurrences))
+
+		// a new limit of doubtful names recounts all names
+		gnt.OddsHigh = 1
+		n, _ = check()
+		Expect(n.Accepted).To(Equal(0))
+	})
+})
+
+// reviewUpTo marks names with indices from 0 to last as reviewed.
+func reviewUpTo(names *Names, last int) *Names {
+	names.Reviewed = make(map[int]bool)
+	for i := 0; i <= last; i++ {
+		names.Reviewed[i] = true
+	}
+	return names
+}
@@ -0,0 +1,59 @@
+package termui_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/annotation"
+	"github.com/gnames/gntagger/keymap"
+)
+
+// BenchmarkKeyToRender measures the time from a key press to rendered views
+// deep into the seashells book, when most of the names are reviewed.
+func BenchmarkKeyToRender(b *testing.B) {
+	dir, err := ioutil.TempDir("", "gntagger_bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	data, err := ioutil.ReadFile(pathLong)
+	if err != nil {
+		b.Fatal(err)
+	}
+	path := filepath.Join(dir, "seashells_book.txt")
+	gnt := NewGnTagger()
+	gnt.Express = false
+	t := NewText(data, path, "abcd")
+	names := PrepareFilesAndText(t, 100-36, gnt)
+	last := len(names.Data.Names) - 2
+	names.Reviewed = make(map[int]bool)
+	for i := 0; i < last; i++ {
+		names.Data.Names[i].Annotation = annotation.Accepted.String()
+		names.Reviewed[i] = true
+	}
+	names.Data.Meta.CurrentName = last
+	s := NewSession(t, names, gnt)
+	km, err := keymap.NewKeymap(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	h, err := newHarness(s, km, 100, 40)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// a decision and a move to the previous name
+		key := "y"
+		if i%2 == 1 {
+			key = "Left"
+		}
+		if err = h.Press(key); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
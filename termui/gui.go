@@ -4,11 +4,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 
-	"github.com/atotto/clipboard"
 	"github.com/gnames/gntagger"
 	"github.com/gnames/gntagger/annotation"
+	"github.com/gnames/gntagger/keymap"
 	"github.com/jroimartin/gocui"
 )
 
@@ -33,50 +34,93 @@ const (
 	ViewHelp
 )
 
-var (
-	gnt                  = &gntagger.GnTagger{}
-	views                = map[ViewType]*Window{}
-	names                = &gntagger.Names{}
-	text                 = &gntagger.Text{}
-	saveCount            = 0
-	nameViewCenterOffset = 0
-	// lastReviewedNameIndex keeps the furtherst checked name so far. It is
-	// important for knowing it in case if we moved back and want to know
-	// how far from the 'edge' we are now.
-	lastReviewedNameIndex = 0
-)
+// UI is the terminal user interface of a curation session. It keeps the
+// session and the state of views, so every session has its own interface.
+type UI struct {
+	// session keeps names, the text, settings and the state of the review.
+	session *gntagger.Session
+	// term gives the size of the screen and access to the clipboard.
+	term  Terminal
+	keys  keymap.Keymap
+	views map[ViewType]*Window
+	// nameViewCenterOffset is the line of the names view where the current
+	// name is shown.
+	nameViewCenterOffset int
+	// showDetails is true when likelihood components of the current name
+	// are shown.
+	showDetails bool
+	// occurrenceStats is true when the stats view shows shares of decisions
+	// about occurrences of names instead of name-strings.
+	occurrenceStats bool
+	// lineStarts keeps offsets of the beginnings of lines of the text.
+	lineStarts []int
+	// textTop is the index of the line shown at the top of the text view.
+	textTop int
+	// textShift is the number of lines the text view is scrolled away from
+	// the current name.
+	textShift int
+	// textName is the index of the name the text view was scrolled for.
+	textName int
+	// namesRows keep positions of names shown in lines of the names view,
+	// -1 is for empty lines.
+	namesRows []int
+	// promptKind is '/' for search, ':' for a jump to a name, 'f' for
+	// a filter and '>' for the command palette.
+	promptKind rune
+	// matches keeps indices of names found by the last search.
+	matches []int
+	// status describes results of the last command of the prompt.
+	status string
+}
+
+// NewUI creates a user interface for a session with the given keys on
+// a terminal.
+func NewUI(s *gntagger.Session, km keymap.Keymap, term Terminal) *UI {
+	ui := &UI{keys: km, views: map[ViewType]*Window{}, term: term}
+	ui.setSession(s)
+	return ui
+}
+
+// setSession makes the interface show a session.
+func (ui *UI) setSession(s *gntagger.Session) {
+	ui.session = s
+	ui.textName = s.Current()
+	ui.lineStarts = ui.textLines()
+}
 
-func initViewsMap(g *gocui.Gui) {
-	maxX, maxY := g.Size()
-	views[ViewNames] = &Window{-1, 3, 35, maxY - 1}
-	views[ViewText] = &Window{35, 3, maxX, maxY - 1}
-	views[ViewHelp] = &Window{-1, maxY - 2, maxX, maxY}
+func (ui *UI) initViewsMap() {
+	maxX, maxY := ui.term.Size()
+	ui.views[ViewNames] = &Window{-1, 3, 35, maxY - 1}
+	ui.views[ViewText] = &Window{35, 3, maxX, maxY - 1}
+	ui.views[ViewHelp] = &Window{-1, maxY - 2, maxX, maxY}
 }
 
 // InitGUI initializes command line interface and sets text and names variables
-func InitGUI(t *gntagger.Text, gntag *gntagger.GnTagger) {
-	gnt = gntag
+func InitGUI(t *gntagger.Text, gnt *gntagger.GnTagger, km keymap.Keymap) {
 	g, err := gocui.NewGui(gocui.OutputNormal)
 	if err != nil {
 		log.Panicln(err)
 	}
 	defer g.Close()
+	ui := &UI{keys: km, views: map[ViewType]*Window{}, term: screen{g}}
 
 	g.Cursor = true
+	g.InputEsc = true
+	g.Mouse = true
 
-	initViewsMap(g)
+	ui.initViewsMap()
 
-	names = gntagger.PrepareFilesAndText(t, views[ViewText].width()-1, gnt)
+	names := gntagger.PrepareFilesAndText(t, ui.views[ViewText].width()-1, gnt)
 	if names.Data.Meta.TotalNames == 0 {
 		g.Close()
 		fmt.Printf("\nNo names had been found in the document\n\n")
 		os.Exit(0)
 	}
 
-	text = t
-	g.SetManagerFunc(Layout)
+	ui.setSession(gntagger.NewSession(t, names, gnt))
+	g.SetManagerFunc(ui.Layout)
 
-	if err := Keybindings(g); err != nil {
+	if err := ui.Keybindings(g); err != nil {
 		log.Panicln(err)
 	}
 
@@ -85,129 +129,165 @@ func InitGUI(t *gntagger.Text, gntag *gntagger.GnTagger) {
 	}
 }
 
-// Keybindings sets hotkeys for oprations on the text and names
-func Keybindings(g *gocui.Gui) error {
-	if err := g.SetKeybinding("", gocui.KeyF4, gocui.ModNone,
-		express); err != nil {
-		return err
-	}
-
-	if err := g.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone,
-		quit); err != nil {
-		return err
-	}
-
-	if err := g.SetKeybinding("", gocui.KeyCtrlS, gocui.ModNone,
-		save); err != nil {
-		return err
-	}
-
-	if err := g.SetKeybinding("", gocui.KeyArrowLeft, gocui.ModNone,
-		listBack); err != nil {
-		return err
-	}
-
-	if err := g.SetKeybinding("", gocui.KeyArrowRight, gocui.ModNone,
-		listForward); err != nil {
-		return err
+// Keybindings sets hotkeys for oprations on the text and names according to
+// the keymap
+func (ui *UI) Keybindings(g Binder) error {
+	for _, b := range ui.bindings() {
+		if err := g.SetKeybinding(b.view, b.key, gocui.ModNone,
+			b.handler); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	if err := g.SetKeybinding("", gocui.KeySpace, gocui.ModNone,
-		noName); err != nil {
-		return err
-	}
+// binding connects a key or a mouse event in a view with a handler.
+// A binding with an empty view works in all views.
+type binding struct {
+	view    string
+	key     interface{}
+	handler func(*gocui.Gui, *gocui.View) error
+}
 
-	if err := g.SetKeybinding("", 'y', gocui.ModNone,
-		yesName); err != nil {
-		return err
+// bindings returns all bindings of the terminal user interface.
+func (ui *UI) bindings() []binding {
+	var res []binding
+	hs := ui.handlers()
+	for _, a := range keymap.Actions {
+		for _, k := range ui.keys[a] {
+			// keys that type characters should not work in the prompt
+			view := "names"
+			if !k.IsRune() && a.In(keymap.Save, keymap.Quit) {
+				view = ""
+			}
+			res = append(res, binding{view, k.Value, hs[a]})
+		}
 	}
 
-	if err := g.SetKeybinding("", 's', gocui.ModNone,
-		speciesName); err != nil {
-		return err
+	for _, k := range append(ui.keys[keymap.Help], keymap.Key{Value: gocui.KeyEsc}) {
+		res = append(res, binding{"overlay", k.Value, ui.closeOverlay})
 	}
 
-	if err := g.SetKeybinding("", 'g', gocui.ModNone,
-		genusName); err != nil {
-		return err
-	}
+	return append(res,
+		binding{"overlay", gocui.KeyArrowDown, scrollOverlayDown},
+		binding{"overlay", gocui.KeyArrowUp, scrollOverlayUp},
+		binding{"names", gocui.MouseLeft, ui.clickName},
+		binding{"text", gocui.MouseLeft, ui.clickText},
+		binding{"text", gocui.MouseWheelUp, ui.wheelUp},
+		binding{"text", gocui.MouseWheelDown, ui.wheelDown},
+		binding{"prompt", gocui.KeyEnter, ui.runPrompt},
+		binding{"prompt", gocui.KeyEsc, ui.closePrompt},
+	)
+}
 
-	if err := g.SetKeybinding("", 'u', gocui.ModNone,
-		uninomialName); err != nil {
-		return err
+// handlers connect actions of the keymap with functions that perform them.
+func (ui *UI) handlers() map[keymap.Action]func(*gocui.Gui, *gocui.View) error {
+	return map[keymap.Action]func(*gocui.Gui, *gocui.View) error{
+		keymap.Next:           ui.listForward,
+		keymap.Back:           ui.listBack,
+		keymap.No:             ui.noName,
+		keymap.Yes:            ui.yesName,
+		keymap.Species:        ui.speciesName,
+		keymap.Genus:          ui.genusName,
+		keymap.Uninomial:      ui.uninomialName,
+		keymap.Expand:         ui.expandName,
+		keymap.Propagation:    ui.propagation,
+		keymap.Order:          ui.reviewOrder,
+		keymap.Unique:         ui.toggleUnique,
+		keymap.NextOccurrence: ui.nextOccurrence,
+		keymap.PrevOccurrence: ui.prevOccurrence,
+		keymap.Exception:      ui.toggleException,
+		keymap.Search:         ui.openSearch,
+		keymap.NextMatch:      ui.nextMatch,
+		keymap.PrevMatch:      ui.prevMatch,
+		keymap.Jump:           ui.openJump,
+		keymap.Filter:         ui.openFilter,
+		keymap.Likelihoods:    ui.toggleDetails,
+		keymap.StatsView:      ui.toggleStatsView,
+		keymap.ScrollUp:       ui.scrollUp,
+		keymap.ScrollDown:     ui.scrollDown,
+		keymap.PageUp:         ui.pageUp,
+		keymap.PageDown:       ui.pageDown,
+		keymap.Snap:           ui.snap,
+		keymap.Express:        ui.express,
+		keymap.Help:           ui.openOverlay,
+		keymap.Palette:        ui.openPalette,
+		keymap.Save:           ui.save,
+		keymap.Quit:           ui.quit,
 	}
-
-	return nil
 }
 
 // Layout describes how different vindows are displayed on the screen
-func Layout(g *gocui.Gui) error {
+func (ui *UI) Layout(g *gocui.Gui) error {
 	var err error
-	initViewsMap(g)
+	ui.initViewsMap()
 
-	if err = viewStats(g); err != nil {
+	if err = ui.viewStats(g); err != nil {
 		return err
 	}
 
-	if err = viewNames(g); err != nil {
+	if err = ui.viewNames(g); err != nil {
 		return err
 	}
 
-	if err = viewText(g); err != nil {
+	if err = ui.viewText(g); err != nil {
 		return err
 	}
 
-	if err = viewHelp(g); err != nil {
+	if err = ui.viewHelp(g); err != nil {
 		return err
 	}
 	return nil
 }
 
-func viewNames(g *gocui.Gui) error {
-	vn := views[ViewNames]
+func (ui *UI) viewNames(g *gocui.Gui) error {
+	vn := ui.views[ViewNames]
 	if v, err := g.SetView("names", vn.x0, vn.y0, vn.x1, vn.y1); err != nil {
 		if err != gocui.ErrUnknownView {
 			return err
 		}
 		v.Title = "Names"
-		err := renderNamesView(g)
+		err := ui.renderNamesView(g)
 		if err != nil {
 			log.Panic(err)
 		}
+		if _, err = g.SetCurrentView("names"); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func viewText(g *gocui.Gui) error {
-	vt := views[ViewText]
+func (ui *UI) viewText(g *gocui.Gui) error {
+	vt := ui.views[ViewText]
 	if v, err := g.SetView("text", vt.x0, vt.y0, vt.x1, vt.y1); err != nil {
 		if err != gocui.ErrUnknownView {
 			return err
 		}
 		v.Title = "Text"
-		if err := renderTextView(g); err != nil {
+		if err := ui.renderTextView(g); err != nil {
 			log.Panic(err)
 		}
 	}
 	return nil
 }
 
-func viewStats(g *gocui.Gui) error {
-	maxX, _ := g.Size()
+func (ui *UI) viewStats(g *gocui.Gui) error {
+	maxX, _ := ui.term.Size()
 	if v, err := g.SetView("stats", -1, -1, maxX, 3); err != nil {
 		if err != gocui.ErrUnknownView {
 			return err
 		}
 		v.Title = "Stats"
-		if err := renderStats(g); err != nil {
+		if err := ui.renderStats(g); err != nil {
 			log.Panic(err)
 		}
 	}
 	return nil
 }
 
-func viewHelp(g *gocui.Gui) error {
-	vh := views[ViewHelp]
+func (ui *UI) viewHelp(g *gocui.Gui) error {
+	vh := ui.views[ViewHelp]
 	if v, err := g.SetView("help", vh.x0, vh.y0, vh.x1, vh.y1); err != nil {
 		if err != gocui.ErrUnknownView {
 			return err
@@ -215,280 +295,364 @@ func viewHelp(g *gocui.Gui) error {
 		v.Frame = false
 		v.BgColor = gocui.ColorWhite
 		v.FgColor = gocui.ColorBlack
-		fmt.Fprintln(v,
-			"→ (yes*) next, ← back, Space no, y yes, s species, "+
-				"g genus, u uninomial, ^S save, ^C exit")
+		fmt.Fprintln(v, ui.helpMessage())
 	}
 	return nil
 }
 
-func express(g *gocui.Gui, _ *gocui.View) error {
-	if gnt.Express {
-		gnt.Express = false
-	} else {
-		gnt.Express = true
+func (ui *UI) express(g *gocui.Gui, _ *gocui.View) error {
+	ui.session.ToggleExpress()
+	return ui.renderStats(g)
+}
+
+func (ui *UI) propagation(g *gocui.Gui, _ *gocui.View) error {
+	ui.session.NextPropagation()
+	return ui.renderStats(g)
+}
+
+func (ui *UI) reviewOrder(g *gocui.Gui, _ *gocui.View) error {
+	ui.session.NextOrder()
+	return ui.renderViews(g)
+}
+
+// Switches between reviewing every occurrence of names and reviewing
+// distinct name-strings
+func (ui *UI) toggleUnique(g *gocui.Gui, _ *gocui.View) error {
+	ui.session.ToggleUnique()
+	return ui.renderViews(g)
+}
+
+func (ui *UI) nextOccurrence(g *gocui.Gui, _ *gocui.View) error {
+	return ui.moveOccurrence(g, 1)
+}
+
+func (ui *UI) prevOccurrence(g *gocui.Gui, _ *gocui.View) error {
+	return ui.moveOccurrence(g, -1)
+}
+
+// Shows another occurrence of the current unique name in the text
+func (ui *UI) moveOccurrence(g *gocui.Gui, step int) error {
+	if !ui.session.UniqueMode {
+		return nil
 	}
+	ui.session.MoveOccurrence(step)
+	return ui.renderViews(g)
+}
 
-	if err := renderStats(g); err != nil {
-		return err
+// Marks the shown occurrence of the current unique name as an exception from
+// the decision about the name
+func (ui *UI) toggleException(g *gocui.Gui, _ *gocui.View) error {
+	if !ui.session.UniqueMode {
+		return nil
 	}
-	return nil
+	ui.session.ToggleException()
+	return ui.renderNamesView(g)
+}
+
+// Shows or hides the likelihood components of the current name
+func (ui *UI) toggleDetails(g *gocui.Gui, _ *gocui.View) error {
+	ui.showDetails = !ui.showDetails
+	if !ui.showDetails {
+		return g.DeleteView("details")
+	}
+	return ui.renderDetails(g)
 }
 
-func quit(g *gocui.Gui, v *gocui.View) error {
-	if err := save(g, v); err != nil {
+// Switches shares of decisions in the stats view between name-strings and
+// occurrences of names
+func (ui *UI) toggleStatsView(g *gocui.Gui, _ *gocui.View) error {
+	ui.occurrenceStats = !ui.occurrenceStats
+	return ui.renderStats(g)
+}
+
+func (ui *UI) quit(g *gocui.Gui, v *gocui.View) error {
+	if err := ui.save(g, v); err != nil {
 		log.Panic(err)
 	}
 	return gocui.ErrQuit
 }
 
-func save(_ *gocui.Gui, _ *gocui.View) error {
-	err := names.Save()
-	return err
+func (ui *UI) save(_ *gocui.Gui, _ *gocui.View) error {
+	return ui.session.Save()
 }
 
-func speciesName(g *gocui.Gui, _ *gocui.View) error {
-	err := setKey(g, annotation.Species)
+func (ui *UI) speciesName(g *gocui.Gui, _ *gocui.View) error {
+	err := ui.setKey(g, annotation.Species)
 	return err
 }
 
-func genusName(g *gocui.Gui, _ *gocui.View) error {
-	err := setKey(g, annotation.Genus)
+func (ui *UI) genusName(g *gocui.Gui, _ *gocui.View) error {
+	err := ui.setKey(g, annotation.Genus)
 	return err
 }
 
-func uninomialName(g *gocui.Gui, _ *gocui.View) error {
-	err := setKey(g, annotation.Uninomial)
+func (ui *UI) uninomialName(g *gocui.Gui, _ *gocui.View) error {
+	err := ui.setKey(g, annotation.Uninomial)
 	return err
 }
 
-func yesName(g *gocui.Gui, _ *gocui.View) error {
-	err := setKey(g, annotation.Accepted)
+func (ui *UI) yesName(g *gocui.Gui, _ *gocui.View) error {
+	err := ui.setKey(g, annotation.Accepted)
 	return err
 }
 
-func noName(g *gocui.Gui, _ *gocui.View) error {
-	err := setKey(g, annotation.NotName)
+func (ui *UI) noName(g *gocui.Gui, _ *gocui.View) error {
+	err := ui.setKey(g, annotation.NotName)
 	return err
 }
 
-// Changes annotation for current and, if required, the following names
-func setKey(g *gocui.Gui, a annotation.Annotation) error {
-	var err error
+// Switches the expansion of an abbreviated current name to the next
+// candidate genus
+func (ui *UI) expandName(g *gocui.Gui, _ *gocui.View) error {
+	ui.session.Names.CycleExpansion()
+	return ui.renderViews(g)
+}
 
-	if err = names.UpdateAnnotations(a, lastReviewedNameIndex, gnt); err != nil {
+// Changes annotation for current and, if required, the following names
+func (ui *UI) setKey(g *gocui.Gui, a annotation.Annotation) error {
+	if err := ui.session.Annotate(a); err != nil {
 		return err
 	}
+	return ui.renderViews(g)
+}
 
-	if err = renderNamesView(g); err != nil {
+func (ui *UI) listForward(g *gocui.Gui, _ *gocui.View) error {
+	if err := ui.session.Next(); err != nil {
 		return err
 	}
-	err = renderTextView(g)
-	return err
+	return ui.renderViews(g)
 }
 
-func listForward(g *gocui.Gui, _ *gocui.View) error {
-	var err error
-	name := names.GetCurrentName()
-	ann, err := annotation.NewAnnotation(name.Annotation)
-	if err != nil {
+func (ui *UI) listBack(g *gocui.Gui, _ *gocui.View) error {
+	ui.session.Back()
+	return ui.renderViews(g)
+}
+
+// goToName makes a name with the given index current. It does not mark
+// names between the edge and the name as reviewed.
+func (ui *UI) goToName(g *gocui.Gui, idx int) error {
+	ui.session.GoTo(idx)
+	return ui.renderViews(g)
+}
+
+// renderViews shows the current state of the session in the names and the
+// text views.
+func (ui *UI) renderViews(g *gocui.Gui) error {
+	if err := ui.renderNamesView(g); err != nil {
 		return err
 	}
+	return ui.renderTextView(g)
+}
 
-	if ann == annotation.NotAssigned {
-		err := setKey(g, annotation.Accepted)
-		if err != nil {
-			return err
-		}
+func (ui *UI) renderTextView(g *gocui.Gui) error {
+	vText, err := g.View("text")
+	if err != nil {
+		log.Panic()
 	}
 
-	step := 1
-	if names.Data.Meta.CurrentName == len(names.Data.Names)-1 ||
-		name.Annotation == annotation.Doubtful.String() {
-		step = 0
-	}
+	_, height := vText.Size()
+	vText.Clear()
 
-	names.Data.Meta.CurrentName += step
-	if gnt.Express && step > 0 {
-		for _, v := range names.Data.Names[names.Data.Meta.CurrentName:] {
-			ann, err := annotation.NewAnnotation(v.Annotation)
-			if err != nil {
-				panic(fmt.Errorf("Uknown annotation %s", v.Annotation))
-			}
-			if ann.In(annotation.NotAssigned, annotation.Doubtful) {
-				break
-			} else {
-				names.Data.Meta.CurrentName += 1
-			}
-		}
+	current := ui.session.Current()
+	if current != ui.textName {
+		ui.textName, ui.textShift = current, 0
+	}
+	name := ui.session.CurrentName()
+	// the line of the current name is next to its "Name:" line in the names
+	// view
+	ui.textTop = ui.lineOf(name.OffsetStart) - ui.nameViewCenterOffset + ui.textShift
+	vText.Title = "Text"
+	if ui.textShift != 0 {
+		vText.Title = fmt.Sprintf("Text (scrolled, %s returns to the name)",
+			ui.keys.Label(keymap.Snap))
 	}
 
-	if names.Data.Meta.CurrentName > lastReviewedNameIndex {
-		lastReviewedNameIndex = names.Data.Meta.CurrentName
+	// the first new line of an empty view does not add a line
+	for i := ui.textTop; i <= 0 && ui.textTop < 0; i++ {
+		fmt.Fprintln(vText)
 	}
-	if err = renderNamesView(g); err != nil {
-		return err
+	first, last := ui.textTop, ui.textTop+height-1
+	if first < 0 {
+		first = 0
 	}
-	if err = renderTextView(g); err != nil {
-		return err
+	if last >= len(ui.lineStarts) {
+		last = len(ui.lineStarts) - 1
 	}
-	return err
-}
-
-func listBack(g *gocui.Gui, _ *gocui.View) error {
-	var err error
-	if names.Data.Meta.CurrentName == 0 {
+	if first > last {
 		return nil
 	}
-	names.Data.Meta.CurrentName--
-	if err = renderNamesView(g); err != nil {
-		return err
-	}
-	if err = renderTextView(g); err != nil {
-		return err
-	}
+	start, end := ui.lineStarts[first], ui.lineEnd(last)
+
+	_, err = fmt.Fprint(vText, ui.highlightNames(start, end))
 	return err
 }
 
-func renderTextView(g *gocui.Gui) error {
-	vText, err := g.View("text")
-	if err != nil {
-		log.Panic()
+// highlightNames returns the text between start and end offsets with all
+// names colored according to their annotations. The current name is bold
+// and is followed by its expansion.
+func (ui *UI) highlightNames(start, end int) string {
+	var b strings.Builder
+	ns := ui.session.Names.Data.Names
+	i := sort.Search(len(ns), func(i int) bool {
+		return ns[i].OffsetEnd > start
+	})
+	pos := start
+	for ; i < len(ns) && ns[i].OffsetStart < end; i++ {
+		ann, err := annotation.NewAnnotation(ns[i].Annotation)
+		if err != nil {
+			continue
+		}
+		nameStart, nameEnd := ns[i].OffsetStart, ns[i].OffsetEnd
+		if nameStart < pos {
+			nameStart = pos
+		}
+		if nameEnd > end {
+			nameEnd = end
+		}
+		if nameStart >= nameEnd {
+			continue
+		}
+		b.WriteString(string(ui.session.Text.Processed[pos:nameStart]))
+		nameStr := string(ui.session.Text.Processed[nameStart:nameEnd])
+		if i == ui.session.Current() {
+			fmt.Fprintf(&b, "\033[40;%d;1m%s\033[0m%s", ann.Color(), nameStr,
+				ui.expansionLabel())
+		} else {
+			fmt.Fprintf(&b, "\033[%dm%s\033[0m", ann.Color(), nameStr)
+		}
+		pos = nameEnd
 	}
+	b.WriteString(string(ui.session.Text.Processed[pos:end]))
+	return b.String()
+}
 
-	_, maxY := g.Size()
-	vText.Clear()
-
-	name := names.GetCurrentName()
-	cursorLeft := name.OffsetStart - 1
-
-	newLinesBefore := 0
-	for ; cursorLeft >= 0 && newLinesBefore <= nameViewCenterOffset; cursorLeft-- {
-		if text.Processed[cursorLeft] == '\n' {
-			newLinesBefore++
-		}
+// expansionLabel returns the expansion of the current name as it is shown
+// after the name in the text view.
+func (ui *UI) expansionLabel() string {
+	if exp := ui.session.Names.ExpandedName(ui.session.Current()); exp != "" {
+		return fmt.Sprintf("\033[36m [%s]\033[0m", exp)
 	}
+	return ""
+}
 
-	newLinesAfter := 0
-	cursorRight := name.OffsetEnd + 1
-	for ; cursorRight < len(text.Processed)-1 && newLinesAfter < maxY/2-1; cursorRight++ {
-		if text.Processed[cursorRight] == '\n' {
-			newLinesAfter++
+// textLines returns offsets of the beginnings of lines of the processed
+// session.Text.
+func (ui *UI) textLines() []int {
+	res := []int{0}
+	for i, r := range ui.session.Text.Processed {
+		if r == '\n' {
+			res = append(res, i+1)
 		}
 	}
+	return res
+}
 
-	ann, err := annotation.NewAnnotation(name.Annotation)
-	if err != nil {
-		return nil
-	}
-	color := ann.Color()
-	for i := 0; i <= nameViewCenterOffset-newLinesBefore; i++ {
-		fmt.Fprintln(vText)
-	}
-	_, err = fmt.Fprintf(vText, "%s\033[40;%d;1m%s\033[0m%s",
-		string(text.Processed[cursorLeft+1:name.OffsetStart]),
-		color,
-		string(text.Processed[name.OffsetStart:name.OffsetEnd]),
-		string(text.Processed[name.OffsetEnd:cursorRight]),
-	)
-	for i := 0; i <= newLinesAfter-nameViewCenterOffset+1; i++ {
-		fmt.Fprintln(vText)
+// lineOf returns the index of a line that contains the given offset.
+func (ui *UI) lineOf(offset int) int {
+	return sort.Search(len(ui.lineStarts), func(i int) bool {
+		return ui.lineStarts[i] > offset
+	}) - 1
+}
+
+// lineEnd returns the offset of the end of a line, including its new line
+// character.
+func (ui *UI) lineEnd(line int) int {
+	if line+1 < len(ui.lineStarts) {
+		return ui.lineStarts[line+1]
 	}
-	return err
+	return len(ui.session.Text.Processed)
 }
 
-func renderNamesView(g *gocui.Gui) error {
+func (ui *UI) renderNamesView(g *gocui.Gui) error {
 	viewNames, err := g.View("names")
 	if err != nil {
 		log.Panic(err)
 	}
 
-	saveCount++
-	if saveCount >= 30 {
-		if err := save(g, viewNames); err != nil {
-			log.Panic(err)
-		}
-		saveCount = 0
+	if err := ui.session.Autosave(); err != nil {
+		log.Panic(err)
 	}
-	_, maxY := g.Size()
+	_, maxY := ui.term.Size()
 	viewNames.Clear()
-	namesTotal := len(names.Data.Names)
+	pos, namesTotal := ui.session.Position()
 	namesSliceWindow := (maxY - 2) / 4 / 2
-	nameViewCenterOffset = (namesSliceWindow+1)*4 - 2
+	ui.nameViewCenterOffset = (namesSliceWindow+1)*4 - 2
 
-	namesSliceLeft := names.Data.Meta.CurrentName - namesSliceWindow
+	namesSliceLeft := pos - namesSliceWindow
 	if namesSliceLeft < 0 {
 		namesSliceLeft = 0
 	}
-	namesSliceRight := names.Data.Meta.CurrentName + namesSliceWindow + 1
+	namesSliceRight := pos + namesSliceWindow + 1
 	if namesSliceRight > namesTotal {
 		namesSliceRight = namesTotal
 	}
+	// the first new line of an empty view does not add a line
 	fmt.Fprintln(viewNames)
-	for i := 0; i <= namesSliceWindow-names.Data.Meta.CurrentName-1; i++ {
+	ui.namesRows = nil
+	for i := 0; i <= namesSliceWindow-pos-1; i++ {
 		for j := 0; j < 4; j++ {
 			fmt.Fprintln(viewNames)
+			ui.namesRows = append(ui.namesRows, -1)
 		}
 	}
 	for i := namesSliceLeft; i < namesSliceRight; i++ {
-		current := i == names.Data.Meta.CurrentName
-		nm := names.Data.Names[i]
-		nameStrs, err := gntagger.NameStrings(&nm, current, i, namesTotal)
+		nameStrs, err := ui.session.RowStrings(i)
 		if err != nil {
 			return err
 		}
 		fmt.Fprintln(viewNames, strings.Join(nameStrs, "\n"))
+		for range nameStrs {
+			ui.namesRows = append(ui.namesRows, i)
+		}
 	}
-	if err = copyCurrentNameToClipboard(); err != nil {
-		text.AddError(fmt.Errorf("\033[31;1mCurrent names did not go to clipboard: %s\033[0m", err))
+	if err = ui.copyCurrentNameToClipboard(); err != nil {
+		ui.session.Text.AddError(fmt.Errorf("\033[31;1mCurrent names did not go to clipboard: %s\033[0m", err))
 	}
 
-	if err = renderStats(g); err != nil {
+	if err = ui.renderStats(g); err != nil {
 		return err
 	}
+	if ui.showDetails {
+		return ui.renderDetails(g)
+	}
 	return nil
 }
 
-func renderStats(g *gocui.Gui) error {
-	var stats Stats
-	maxX, _ := g.Size()
-	wordStates := make(map[string]*WordState)
+// renderDetails shows a table of likelihood components of the current name
+// in the top right corner of the text view.
+func (ui *UI) renderDetails(g *gocui.Gui) error {
+	lines := ui.session.Names.LikelihoodStrings(ui.session.Current())
+	vt := ui.views[ViewText]
+	x0 := vt.x1 - 48
+	if x0 < vt.x0 {
+		x0 = vt.x0
+	}
+	v, err := g.SetView("details", x0, vt.y0, vt.x1, vt.y0+len(lines)+1)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	v.Title = fmt.Sprintf("Likelihoods (%s)", ui.keys.Label(keymap.Likelihoods))
+	v.Clear()
+	_, err = fmt.Fprint(v, strings.Join(lines, "\n"))
+	return err
+}
+
+func (ui *UI) renderStats(g *gocui.Gui) error {
+	maxX, _ := ui.term.Size()
 	viewStats, err := g.View("stats")
 	if err != nil {
 		log.Panic(err)
 	}
-
-	for nameIdx := 0; nameIdx <= lastReviewedNameIndex; nameIdx++ {
-		name := names.Data.Names[nameIdx]
-		ann, err := annotation.NewAnnotation(name.Annotation)
-		if err != nil {
-			return err
-		}
-		ws, ok := wordStates[name.Name]
-		if !ok {
-			ws = &WordState{}
-			wordStates[name.Name] = ws
-		}
-		switch ann {
-		case annotation.NotName:
-			ws.rejected = true
-		case annotation.Accepted:
-			ws.accepted = true
-		case annotation.Uninomial, annotation.Genus, annotation.Species:
-			ws.modified = true
-		}
-
-		if name.Odds != 0.0 && name.Odds < gnt.OddsHigh {
-			ws.doubtful = true
-		}
+	names, occurrences, err := ui.session.Stats()
+	if err != nil {
+		return err
 	}
 
-	updateStats(&stats, wordStates)
 	viewStats.Clear()
 	fmt.Fprintln(viewStats)
 	fmt.Fprintln(viewStats)
-	statsStr := stats.format()
-	statsStrVisibleLen := maxX - len(statsStr) + 40 // to compensate invisible chars
+	statsStr := ui.formatStats(names, occurrences)
+	statsStrVisibleLen := maxX - visibleLen(statsStr) - 1
 	for i := 0; i < statsStrVisibleLen; i++ {
 		fmt.Fprint(viewStats, " ")
 	}
@@ -497,30 +661,6 @@ func renderStats(g *gocui.Gui) error {
 	return err
 }
 
-func updateStats(s *Stats, wordStates map[string]*WordState) {
-	for _, ws := range wordStates {
-		if ws.doubtful {
-			if ws.accepted || ws.modified {
-				s.addedCount++
-				s.total++
-			}
-		} else {
-			if ws.accepted {
-				s.acceptedCount++
-				s.total++
-			}
-			if ws.rejected {
-				s.rejectedCount++
-				s.total++
-			}
-			if ws.modified {
-				s.modifiedCount++
-				s.total++
-			}
-		}
-	}
-}
-
-func copyCurrentNameToClipboard() error {
-	return clipboard.WriteAll(names.GetCurrentName().Name)
+func (ui *UI) copyCurrentNameToClipboard() error {
+	return ui.term.Copy(ui.session.CurrentName().Name)
 }
@@ -0,0 +1,184 @@
+package termui_test
+
+import (
+	"fmt"
+
+	. "github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/keymap"
+	"github.com/gnames/gntagger/termui"
+	"github.com/jroimartin/gocui"
+)
+
+// harness runs the terminal user interface without a terminal. It is the
+// Terminal and the Binder of the interface: it keeps the size of a simulated
+// screen and the clipboard, and records handlers registered by
+// termui.UI.Keybindings. Keys and mouse clicks run recorded handlers the way
+// gocui does, views are rendered into in-memory buffers.
+type harness struct {
+	g        *gocui.Gui
+	ui       *termui.UI
+	bindings []binding
+	// width and height are the size of the simulated screen.
+	width, height int
+	// clipboard keeps the last copied string.
+	clipboard string
+}
+
+// binding is a handler registered for a key in a view.
+type binding struct {
+	view    string
+	key     interface{}
+	handler func(*gocui.Gui, *gocui.View) error
+}
+
+// newHarness creates a user interface for a session on a simulated screen
+// of the given size. The text of the session should be wrapped to the width
+// of the text view, which is 36 characters less than the width of the
+// screen.
+func newHarness(s *Session, km keymap.Keymap, width,
+	height int) (*harness, error) {
+	h := &harness{g: &gocui.Gui{}, width: width, height: height}
+	h.ui = termui.NewUI(s, km, h)
+	if err := h.ui.Keybindings(h); err != nil {
+		return nil, err
+	}
+	if err := h.ui.Layout(h.g); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *harness) Size() (int, int) {
+	return h.width, h.height
+}
+
+func (h *harness) Copy(s string) error {
+	h.clipboard = s
+	return nil
+}
+
+func (h *harness) SetKeybinding(view string, key interface{},
+	_ gocui.Modifier, handler func(*gocui.Gui, *gocui.View) error) error {
+	h.bindings = append(h.bindings, binding{view, key, handler})
+	return nil
+}
+
+// Press sends keys to the user interface. Keys are named as in the keys
+// section of the configuration file, and "Esc" is the escape key. It
+// returns gocui.ErrQuit after the quit key.
+func (h *harness) Press(names ...string) error {
+	for _, name := range names {
+		var value interface{} = gocui.KeyEsc
+		if name != "Esc" {
+			k, err := keymap.NewKey(name)
+			if err != nil {
+				return err
+			}
+			value = k.Value
+		}
+		if err := h.send(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Type types a text, usually into a prompt.
+func (h *harness) Type(s string) error {
+	for _, r := range s {
+		var value interface{} = r
+		if r == ' ' {
+			value = gocui.KeySpace
+		}
+		if err := h.send(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Click clicks the left button of the mouse at the given position of the
+// screen.
+func (h *harness) Click(x, y int) error {
+	v, err := h.g.ViewByPosition(x, y)
+	if err != nil {
+		return nil
+	}
+	x0, y0, _, _, err := h.g.ViewPosition(v.Name())
+	if err != nil {
+		return err
+	}
+	if err = v.SetCursor(x-x0-1, y-y0-1); err != nil {
+		return err
+	}
+	if err = h.exec(v, gocui.MouseLeft); err != nil && err != errNotBound {
+		return err
+	}
+	return h.ui.Layout(h.g)
+}
+
+// View returns the text shown in a view without colors. It returns an
+// empty string if the view is not shown.
+func (h *harness) View(name string) string {
+	v, err := h.g.View(name)
+	if err != nil {
+		return ""
+	}
+	return v.Buffer()
+}
+
+// Title returns the title of a view. It returns an empty string if the view
+// is not shown.
+func (h *harness) Title(name string) string {
+	v, err := h.g.View(name)
+	if err != nil {
+		return ""
+	}
+	return v.Title
+}
+
+// CurrentView returns the name of the view that receives keys.
+func (h *harness) CurrentView() string {
+	if v := h.g.CurrentView(); v != nil {
+		return v.Name()
+	}
+	return ""
+}
+
+// send runs handlers of a key in the current view, like gocui does. Keys
+// without handlers are typed into editable views.
+func (h *harness) send(value interface{}) error {
+	v := h.g.CurrentView()
+	err := h.exec(v, value)
+	if err == errNotBound && v != nil && v.Editable && v.Editor != nil {
+		if r, ok := value.(rune); ok {
+			v.Editor.Edit(v, 0, r, gocui.ModNone)
+		} else {
+			v.Editor.Edit(v, value.(gocui.Key), 0, gocui.ModNone)
+		}
+	} else if err != nil && err != errNotBound {
+		return err
+	}
+	return h.ui.Layout(h.g)
+}
+
+var errNotBound = fmt.Errorf("key is not bound")
+
+// exec runs handlers registered for a key in a view and in all views. It
+// returns errNotBound if there are no such handlers.
+func (h *harness) exec(v *gocui.View, value interface{}) error {
+	matched := false
+	for _, b := range h.bindings {
+		if b.key != value || (b.view != "" && (v == nil || v.Name() != b.view)) {
+			continue
+		}
+		if err := b.handler(h.g, v); err != nil {
+			return err
+		}
+		matched = true
+	}
+	if !matched {
+		return errNotBound
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package termui
+
+import (
+	"sort"
+
+	"github.com/jroimartin/gocui"
+)
+
+// wheelLines is the number of lines the text scrolls with one turn of the
+// mouse wheel.
+const wheelLines = 3
+
+// Makes current a name clicked in the names view
+func (ui *UI) clickName(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	if cy >= len(ui.namesRows) || ui.namesRows[cy] < 0 {
+		return nil
+	}
+	return ui.goToName(g, ui.session.NameAt(ui.namesRows[cy]))
+}
+
+// Makes current a name clicked in the text view
+func (ui *UI) clickText(g *gocui.Gui, v *gocui.View) error {
+	cx, cy := v.Cursor()
+	line := ui.textTop + cy
+	if line < 0 || line >= len(ui.lineStarts) {
+		return nil
+	}
+	offset := ui.lineStarts[line] + cx
+	// the expansion of the current name shifts the rest of its line
+	name := ui.session.CurrentName()
+	if ui.lineOf(name.OffsetEnd) == line && offset >= name.OffsetEnd {
+		offset -= visibleLen(ui.expansionLabel())
+		if offset < name.OffsetEnd {
+			return nil
+		}
+	}
+	if offset >= ui.lineEnd(line) {
+		return nil
+	}
+	ns := ui.session.Names.Data.Names
+	i := sort.Search(len(ns), func(i int) bool {
+		return ns[i].OffsetEnd > offset
+	})
+	if i == len(ns) || ns[i].OffsetStart > offset {
+		return nil
+	}
+	return ui.goToName(g, i)
+}
+
+func (ui *UI) wheelUp(g *gocui.Gui, _ *gocui.View) error {
+	return ui.scrollText(g, -wheelLines)
+}
+
+func (ui *UI) wheelDown(g *gocui.Gui, _ *gocui.View) error {
+	return ui.scrollText(g, wheelLines)
+}
@@ -0,0 +1,148 @@
+package termui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gnames/gntagger/annotation"
+	"github.com/gnames/gntagger/keymap"
+	"github.com/jroimartin/gocui"
+)
+
+var meanings = []struct {
+	ann     annotation.Annotation
+	meaning string
+}{
+	{annotation.NotAssigned, "not reviewed yet, moving forward accepts the name"},
+	{annotation.Accepted, "the name is correct"},
+	{annotation.NotName, "not a scientific name"},
+	{annotation.Uninomial, "only the first word is a name, a uninomial"},
+	{annotation.Genus, "only the first word is a name, a genus"},
+	{annotation.Species, "only the first two words are a name, a species"},
+	{annotation.Doubtful, "the score of the name is low, it needs a decision"},
+}
+
+// Shows a full-screen help with all keys, modes and annotations
+func (ui *UI) openOverlay(g *gocui.Gui, _ *gocui.View) error {
+	maxX, maxY := ui.term.Size()
+	v, err := g.SetView("overlay", 2, 1, maxX-3, maxY-2)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	v.Title = fmt.Sprintf("Help (%s or Esc to close, Up/Down to scroll)",
+		ui.keys.Label(keymap.Help))
+	v.Wrap = true
+	v.Clear()
+	if err = v.SetOrigin(0, 0); err != nil {
+		return err
+	}
+	fmt.Fprint(v, ui.overlayText())
+	_, err = g.SetCurrentView("overlay")
+	return err
+}
+
+func (ui *UI) closeOverlay(g *gocui.Gui, _ *gocui.View) error {
+	if err := g.DeleteView("overlay"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("names")
+	return err
+}
+
+func scrollOverlayDown(_ *gocui.Gui, v *gocui.View) error {
+	x, y := v.Origin()
+	if y+1 >= len(v.BufferLines()) {
+		return nil
+	}
+	return v.SetOrigin(x, y+1)
+}
+
+func scrollOverlayUp(_ *gocui.Gui, v *gocui.View) error {
+	x, y := v.Origin()
+	if y == 0 {
+		return nil
+	}
+	return v.SetOrigin(x, y-1)
+}
+
+// overlayText describes keys, modes with their current state, annotations
+// and commands of the palette.
+func (ui *UI) overlayText() string {
+	var b strings.Builder
+	heading := func(s string) {
+		fmt.Fprintf(&b, "\n \033[33;1m%s\033[0m\n\n", s)
+	}
+
+	heading("Keys")
+	for _, a := range keymap.Actions {
+		fmt.Fprintf(&b, "  %-16s %s\n", ui.keys.Label(a), a.Description())
+	}
+
+	heading("Modes")
+	onOff := func(on bool) string {
+		if on {
+			return "on"
+		}
+		return "off"
+	}
+	statsState := "names"
+	if ui.occurrenceStats {
+		statsState = "occurrences"
+	}
+	filterState := "none"
+	if ui.session.Filter != nil {
+		filterState = ui.session.FilterText
+	}
+	modes := []struct {
+		action      keymap.Action
+		name, state string
+		description string
+	}{
+		{keymap.Express, "Express", onOff(ui.session.GnTagger.Express),
+			"moving forward skips names that already have decisions"},
+		{keymap.Propagation, "Propagation", ui.session.GnTagger.Propagation.Format(ui.session.GnTagger),
+			"which following names receive a decision: none, the same " +
+				"name-string, the same name-string and type, the same " +
+				"name-string within a number of pages"},
+		{keymap.Order, "Order", ui.session.GnTagger.Order.String(),
+			"document order, or informative order that shows first " +
+				"occurrences of the least certain names first"},
+		{keymap.Unique, "Unique", onOff(ui.session.UniqueMode),
+			"a decision is made for all occurrences of a name-string, " +
+				"exceptions receive the opposite decision"},
+		{keymap.Filter, "Filter", filterState,
+			"only matching names are shown, for example " +
+				"'ann:NotName,new type:Uninomial odds:-1..2'"},
+		{keymap.Likelihoods, "Likelihoods", onOff(ui.showDetails),
+			"a table of features from which the score of a name is calculated"},
+		{keymap.StatsView, "Stats", statsState,
+			"shares of decisions are counted for distinct name-strings, or " +
+				"for every occurrence of names"},
+	}
+	for _, m := range modes {
+		fmt.Fprintf(&b, "  %-12s (%s) \033[32m%s\033[0m: %s\n", m.name,
+			ui.keys.Label(m.action), m.state, m.description)
+	}
+
+	heading("Annotations")
+	for _, m := range meanings {
+		name := m.ann.String()
+		if name == "" {
+			name = "(empty)"
+		}
+		fmt.Fprintf(&b, "  \033[%d;1m%-10s\033[0m %s\n", m.ann.Color(), name,
+			m.meaning)
+	}
+
+	heading(fmt.Sprintf("Commands (%s)", ui.keys.Label(keymap.Palette)))
+	var cmds []string
+	for name := range ui.commands() {
+		cmds = append(cmds, name)
+	}
+	sort.Strings(cmds)
+	fmt.Fprintf(&b, "  %s\n", strings.Join(cmds, ", "))
+	fmt.Fprintf(&b, "\n  A command can be shortened, jump, filter and search "+
+		"accept an argument,\n  for example 'jump 12' or 'fil ann:Doubtful'.\n")
+	return b.String()
+}
@@ -0,0 +1,249 @@
+package termui
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/keymap"
+	"github.com/jroimartin/gocui"
+)
+
+// promptLabels are shown before the text typed into the prompt.
+var promptLabels = map[rune]string{'/': "/", ':': ":", 'f': "Filter: ",
+	'>': "> "}
+
+func (ui *UI) openSearch(g *gocui.Gui, _ *gocui.View) error {
+	return ui.openPrompt(g, '/')
+}
+
+func (ui *UI) openJump(g *gocui.Gui, _ *gocui.View) error {
+	return ui.openPrompt(g, ':')
+}
+
+func (ui *UI) openFilter(g *gocui.Gui, _ *gocui.View) error {
+	return ui.openPrompt(g, 'f')
+}
+
+func (ui *UI) openPalette(g *gocui.Gui, _ *gocui.View) error {
+	return ui.openPrompt(g, '>')
+}
+
+// Opens a one-line prompt on top of the help line
+func (ui *UI) openPrompt(g *gocui.Gui, kind rune) error {
+	ui.promptKind = kind
+	label := promptLabels[kind]
+	vh := ui.views[ViewHelp]
+	v, err := g.SetView("prompt", vh.x0+len(label), vh.y0, vh.x1, vh.y1)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	v.Frame = false
+	v.Editable = true
+	v.BgColor = gocui.ColorWhite
+	v.FgColor = gocui.ColorBlack
+	v.Clear()
+	if err = v.SetCursor(0, 0); err != nil {
+		return err
+	}
+	if _, err = g.SetCurrentView("prompt"); err != nil {
+		return err
+	}
+	if kind == 'f' {
+		fmt.Fprint(v, ui.session.FilterText)
+		if err = v.SetCursor(len(ui.session.FilterText), 0); err != nil {
+			return err
+		}
+	}
+	return ui.renderHelp(g, label)
+}
+
+// Closes the prompt and returns control to the names view
+func (ui *UI) closePrompt(g *gocui.Gui, _ *gocui.View) error {
+	if err := g.DeleteView("prompt"); err != nil {
+		return err
+	}
+	if _, err := g.SetCurrentView("names"); err != nil {
+		return err
+	}
+	return ui.renderHelp(g, ui.helpMessage())
+}
+
+// Runs the search, the jump, the filter or the command typed into the prompt
+func (ui *UI) runPrompt(g *gocui.Gui, v *gocui.View) error {
+	input := strings.TrimSpace(v.Buffer())
+	if err := ui.closePrompt(g, v); err != nil {
+		return err
+	}
+	switch ui.promptKind {
+	case 'f':
+		return ui.applyFilter(g, input)
+	case '>':
+		if input == "" {
+			return nil
+		}
+		return ui.runCommand(g, input)
+	case ':':
+		if input == "" {
+			return nil
+		}
+		return ui.jump(g, input)
+	default:
+		if input == "" {
+			return nil
+		}
+		return ui.search(g, input)
+	}
+}
+
+// jump goes to a name with the number shown in the names view.
+func (ui *UI) jump(g *gocui.Gui, input string) error {
+	num, err := strconv.Atoi(input)
+	if err != nil {
+		ui.status = fmt.Sprintf("Not a number: %s", input)
+		return ui.renderHelp(g, ui.helpMessage())
+	}
+	ui.session.JumpTo(num)
+	return ui.renderViews(g)
+}
+
+// search finds names and text that match a regular expression and goes to
+// the first match after the current name.
+func (ui *UI) search(g *gocui.Gui, input string) error {
+	re, err := regexp.Compile(input)
+	if err != nil {
+		ui.matches = nil
+		ui.status = fmt.Sprintf("Bad search /%s/", input)
+		return ui.renderHelp(g, ui.helpMessage())
+	}
+	ui.matches = ui.session.Search(re)
+	ui.status = fmt.Sprintf("/%s/ %d matches", input, len(ui.matches))
+	if err = ui.renderHelp(g, ui.helpMessage()); err != nil {
+		return err
+	}
+	return ui.nextMatch(g, nil)
+}
+
+func (ui *UI) nextMatch(g *gocui.Gui, _ *gocui.View) error {
+	return ui.goToMatch(g, true)
+}
+
+func (ui *UI) prevMatch(g *gocui.Gui, _ *gocui.View) error {
+	return ui.goToMatch(g, false)
+}
+
+func (ui *UI) goToMatch(g *gocui.Gui, forward bool) error {
+	idx := gntagger.NextMatch(ui.matches, ui.session.Current(), forward)
+	if idx < 0 {
+		return nil
+	}
+	return ui.goToName(g, idx)
+}
+
+// applyFilter restricts navigation to names that match a filter. An empty
+// input removes the filter.
+func (ui *UI) applyFilter(g *gocui.Gui, input string) error {
+	f, err := gntagger.NewFilter(input)
+	if err != nil {
+		ui.status = fmt.Sprintf("Bad filter: %s", err)
+		return ui.renderHelp(g, ui.helpMessage())
+	}
+	ui.status = ""
+	if !ui.session.SetFilter(f, input) {
+		ui.status = fmt.Sprintf("No names match filter '%s'", input)
+	}
+	if err = ui.renderHelp(g, ui.helpMessage()); err != nil {
+		return err
+	}
+	return ui.renderViews(g)
+}
+
+// commands returns functions of the command palette. They include all
+// actions of the keymap and actions without keys.
+func (ui *UI) commands() map[string]func(*gocui.Gui, *gocui.View) error {
+	res := map[string]func(*gocui.Gui, *gocui.View) error{
+		"export": ui.export,
+	}
+	for a, h := range ui.handlers() {
+		res[string(a)] = h
+	}
+	return res
+}
+
+// commandNames returns sorted names of commands that start with a prefix.
+// If a name is equal to the prefix, only this name is returned.
+func (ui *UI) commandNames(prefix string) []string {
+	var res []string
+	for name := range ui.commands() {
+		if name == prefix {
+			return []string{name}
+		}
+		if strings.HasPrefix(name, prefix) {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// runCommand runs a command of the palette by its name or by an unambiguous
+// beginning of the name. Commands jump, filter and search accept their
+// argument after a space, without an argument they open their prompts.
+func (ui *UI) runCommand(g *gocui.Gui, input string) error {
+	fields := strings.SplitN(input, " ", 2)
+	found := ui.commandNames(fields[0])
+	if len(found) == 0 {
+		ui.status = fmt.Sprintf("Unknown command '%s'", fields[0])
+		return ui.renderHelp(g, ui.helpMessage())
+	}
+	if len(found) > 1 {
+		ui.status = fmt.Sprintf("Ambiguous command '%s': %s", fields[0],
+			strings.Join(found, ", "))
+		return ui.renderHelp(g, ui.helpMessage())
+	}
+
+	if len(fields) == 2 && strings.TrimSpace(fields[1]) != "" {
+		arg := strings.TrimSpace(fields[1])
+		switch keymap.Action(found[0]) {
+		case keymap.Jump:
+			return ui.jump(g, arg)
+		case keymap.Filter:
+			return ui.applyFilter(g, arg)
+		case keymap.Search:
+			return ui.search(g, arg)
+		}
+	}
+	return ui.commands()[found[0]](g, nil)
+}
+
+// Writes curated names to a tab-separated file in the session directory
+func (ui *UI) export(g *gocui.Gui, _ *gocui.View) error {
+	path := filepath.Join(ui.session.Text.Path, gntagger.ExportFile)
+	if err := ui.session.Names.Export(path); err != nil {
+		ui.status = fmt.Sprintf("Export failed: %s", err)
+	} else {
+		ui.status = fmt.Sprintf("Exported to %s", path)
+	}
+	return ui.renderHelp(g, ui.helpMessage())
+}
+
+func (ui *UI) helpMessage() string {
+	if ui.status == "" {
+		return ui.keys.Help()
+	}
+	return ui.status + " | " + ui.keys.Help()
+}
+
+func (ui *UI) renderHelp(g *gocui.Gui, msg string) error {
+	v, err := g.View("help")
+	if err != nil {
+		return err
+	}
+	v.Clear()
+	_, err = fmt.Fprintln(v, msg)
+	return err
+}
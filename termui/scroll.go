@@ -0,0 +1,73 @@
+package termui
+
+import (
+	"github.com/jroimartin/gocui"
+)
+
+// The text view is scrolled independently of the current name, so previous
+// paragraphs can be read for context. Moving to another name, or the snap
+// key, centers the text on the current name again.
+
+func (ui *UI) scrollUp(g *gocui.Gui, _ *gocui.View) error {
+	return ui.scrollText(g, -1)
+}
+
+func (ui *UI) scrollDown(g *gocui.Gui, _ *gocui.View) error {
+	return ui.scrollText(g, 1)
+}
+
+func (ui *UI) pageUp(g *gocui.Gui, _ *gocui.View) error {
+	return ui.scrollText(g, -pageLines(g))
+}
+
+func (ui *UI) pageDown(g *gocui.Gui, _ *gocui.View) error {
+	return ui.scrollText(g, pageLines(g))
+}
+
+// snap returns the text view to the current name.
+func (ui *UI) snap(g *gocui.Gui, _ *gocui.View) error {
+	ui.textShift = 0
+	return ui.renderTextView(g)
+}
+
+// pageLines is the number of lines of one page of the text view. One line
+// of the previous page stays visible.
+func pageLines(g *gocui.Gui) int {
+	v, err := g.View("text")
+	if err != nil {
+		return 1
+	}
+	_, height := v.Size()
+	return max(height-1, 1)
+}
+
+// scrollText moves the text view by a number of lines without changing the
+// current name. The text does not scroll beyond its beginning or end, but
+// it returns to empty lines above the beginning that are shown when the
+// current name is close to the beginning.
+func (ui *UI) scrollText(g *gocui.Gui, lines int) error {
+	top := ui.textTop + lines
+	first := min(ui.textTop-ui.textShift, 0)
+	if lines < 0 && top < first {
+		top = min(ui.textTop, first)
+	}
+	if lines > 0 && top >= len(ui.lineStarts) {
+		top = max(ui.textTop, len(ui.lineStarts)-1)
+	}
+	ui.textShift += top - ui.textTop
+	return ui.renderTextView(g)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
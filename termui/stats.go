@@ -2,130 +2,95 @@ package termui
 
 import (
 	"fmt"
+	"regexp"
+	"unicode/utf8"
 
-	"math"
-
+	"github.com/gnames/gntagger"
 	"github.com/gnames/gntagger/annotation"
+	"github.com/gnames/gntagger/keymap"
 )
 
-// WordState keeps data about a name. It allows us to collect statistics about
-// the quality of the name-finding algorithms.
-type WordState struct {
-	accepted bool
-	rejected bool
-	modified bool
-	doubtful bool
-}
-
-// Stats is a collection of fields needed for calculating statistics.
-type Stats struct {
-	acceptedCount int
-	rejectedCount int
-	modifiedCount int
-	addedCount    int
-	total         int
-
-	acceptedPercent int
-	rejectedPercent int
-	modifiedPercent int
-	addedPercent    int
-}
-
-func (s *Stats) precision() float32 {
-	tpos := float32(s.acceptedCount)
-	fpos := float32(s.rejectedCount + s.modifiedCount)
-	return tpos / (tpos + fpos)
-}
+var escapeRe = regexp.MustCompile("\033\\[[0-9;]*m")
 
-func (s *Stats) recall() float32 {
-	tpos := float32(s.acceptedCount)
-	fneg := float32(s.addedCount)
-	return tpos / (tpos + fneg)
+// visibleLen returns the number of characters of a string visible in
+// terminal.
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(escapeRe.ReplaceAllString(s, ""))
 }
 
-func (s *Stats) calculatePercentage() {
-	if s.total == 0 {
-		return
+// formatStats composes the header of the stats view with modes of the
+// session and statistics of decisions. Precision, recall and F1 are shown
+// for name-strings and for occurrences, the active one in brackets. Shares
+// of decisions are shown for the active one.
+func (ui *UI) formatStats(names, occurrences gntagger.Stats) string {
+	var acceptedPercentStr, rejectedPercentStr, modifiedPercentStr,
+		addedPercentStr string
+
+	namesLabel, occurrencesLabel := "[names]", "occ."
+	s := names
+	if ui.occurrenceStats {
+		namesLabel, occurrencesLabel = "names", "[occ.]"
+		s = occurrences
 	}
 
-	acceptedRate := float32(s.acceptedCount) / float32(s.total)
-	rejectedRate := float32(s.rejectedCount) / float32(s.total)
-	modifiedRate := float32(s.modifiedCount) / float32(s.total)
-	addedRate := float32(s.addedCount) / float32(s.total)
-
-	percent := func(rate float32) int { return int(rate * 100) }
-
-	s.acceptedPercent = percent(acceptedRate)
-	s.rejectedPercent = percent(rejectedRate)
-	s.modifiedPercent = percent(modifiedRate)
-	s.addedPercent = percent(addedRate)
-
-	percents := []*int{&s.acceptedPercent, &s.rejectedPercent, &s.modifiedPercent, &s.addedPercent}
-	rates := []float32{acceptedRate, rejectedRate, modifiedRate, addedRate}
-
-	totalPercent := func() int {
-		return s.acceptedPercent + s.rejectedPercent + s.modifiedPercent + s.addedPercent
-	}
-
-	for totalPercent() < 100 {
-		maxID := -1
-		for i := range percents {
-			if percent(rates[i])-*percents[i] < 0 {
-				continue
-			}
-			if maxID == -1 {
-				maxID = i
-				continue
-			}
-			rateIf64 := float64(rates[i])
-			rateMaxf64 := float64(rates[maxID])
-			if (rateIf64 - math.Floor(rateIf64)) >
-				(rateMaxf64 - math.Floor(rateMaxf64)) {
-				maxID = i
-			}
-		}
-		*percents[maxID]++
-	}
-}
-
-func (s *Stats) format() string {
-	var (
-		precisionStr, recallStr, acceptedPercentStr             string
-		rejectedPercentStr, modifiedPercentStr, addedPercentStr string
-	)
-
-	if s.total == 0 {
-		precisionStr = "0.00"
-		recallStr = "0.00"
+	if s.Total == 0 {
 		acceptedPercentStr = "  0%"
 		rejectedPercentStr = "  0%"
 		modifiedPercentStr = "  0%"
 		addedPercentStr = "  0%"
 	} else {
-		s.calculatePercentage()
-		precisionStr = fmt.Sprintf("%.2f", s.precision())
-		recallStr = fmt.Sprintf("%.2f", s.recall())
-		acceptedPercentStr = fmt.Sprintf("%3d%%", s.acceptedPercent)
-		rejectedPercentStr = fmt.Sprintf("%3d%%", s.rejectedPercent)
-		modifiedPercentStr = fmt.Sprintf("%3d%%", s.modifiedPercent)
-		addedPercentStr = fmt.Sprintf("%3d%%", s.addedPercent)
+		acc, rej, mod, add := s.Percentages()
+		acceptedPercentStr = fmt.Sprintf("%3d%%", acc)
+		rejectedPercentStr = fmt.Sprintf("%3d%%", rej)
+		modifiedPercentStr = fmt.Sprintf("%3d%%", mod)
+		addedPercentStr = fmt.Sprintf("%3d%%", add)
+	}
+	prf := func(s gntagger.Stats) string {
+		return fmt.Sprintf("%.2f/%.2f/%.2f", s.Precision(), s.Recall(), s.F1())
 	}
 
 	skipRepetition := "N"
-	if gnt.Express {
+	if ui.session.GnTagger.Express {
 		skipRepetition = "Y"
 	}
 
-	statsStr := fmt.Sprintf(
-		"\033[33mSkip checked (F4) %s\033[0m | "+
-			"Precision: %s, Recall: %s | "+
+	unique := "N"
+	if ui.session.UniqueMode {
+		unique = "Y"
+	}
+
+	filterStr := ""
+	if ui.session.Filter != nil {
+		filterStr = fmt.Sprintf("\033[31;1mFilter (%s) %s\033[0m | ",
+			ui.keys.Label(keymap.Filter), ui.session.FilterText)
+	}
+
+	statsStr := filterStr + fmt.Sprintf(
+		"\033[33mSkip checked (%s) %s\033[0m | "+
+			"\033[33mPropagate (%s) %s: %d\033[0m | "+
+			"\033[33mOrder (%s) %s\033[0m | "+
+			"\033[33mUnique (%s) %s\033[0m | "+
+			"Lang %s | "+
+			"P/R/F1 (%s) %s %s %s %s | "+
 			"\033[%d;1mAcc. %s "+
 			"\033[%d;1mRej. %s "+
 			"\033[%d;1mMod. %s "+
 			"\033[%d;1mAdd. %s \033[0m",
+		ui.keys.Label(keymap.Express),
 		skipRepetition,
-		precisionStr,
-		recallStr,
+		ui.keys.Label(keymap.Propagation),
+		ui.session.GnTagger.Propagation.Format(ui.session.GnTagger),
+		ui.session.Propagated,
+		ui.keys.Label(keymap.Order),
+		ui.session.GnTagger.Order,
+		ui.keys.Label(keymap.Unique),
+		unique,
+		ui.session.Names.LanguageLabel(),
+		ui.keys.Label(keymap.StatsView),
+		namesLabel,
+		prf(names),
+		occurrencesLabel,
+		prf(occurrences),
 		annotation.Accepted.Color(),
 		acceptedPercentStr,
 		annotation.NotName.Color(),
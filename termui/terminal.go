@@ -0,0 +1,35 @@
+package termui
+
+import (
+	"github.com/atotto/clipboard"
+	"github.com/jroimartin/gocui"
+)
+
+// Terminal gives the user interface the size of the screen and access to
+// the clipboard.
+type Terminal interface {
+	// Size returns the width and the height of the screen.
+	Size() (int, int)
+	// Copy puts a string into the clipboard.
+	Copy(s string) error
+}
+
+// Binder registers handlers of keys and mouse events. *gocui.Gui is
+// a Binder.
+type Binder interface {
+	SetKeybinding(viewname string, key interface{}, mod gocui.Modifier,
+		handler func(*gocui.Gui, *gocui.View) error) error
+}
+
+// screen is the Terminal of a running gocui user interface.
+type screen struct {
+	g *gocui.Gui
+}
+
+func (s screen) Size() (int, int) {
+	return s.g.Size()
+}
+
+func (screen) Copy(s string) error {
+	return clipboard.WriteAll(s)
+}
@@ -0,0 +1,36 @@
+package termui_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+const (
+	pathLong  = "../testdata/seashells_book.txt"
+	pathShort = "../testdata/short.txt"
+)
+
+var dataShort []byte
+
+func TestTermui(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Termui Suite")
+}
+
+var _ = BeforeSuite(func() {
+	var err error
+	dataShort, err = ioutil.ReadFile(pathShort)
+	Expect(err).ToNot(HaveOccurred())
+})
+
+var _ = AfterSuite(func() {
+	if harnessTemplate != "" {
+		err := os.RemoveAll(harnessTemplate)
+		Expect(err).ToNot(HaveOccurred())
+	}
+})
@@ -0,0 +1,238 @@
+package termui_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/annotation"
+	"github.com/gnames/gntagger/keymap"
+	"github.com/jroimartin/gocui"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Termui", func() {
+	var (
+		dir string
+		s   *Session
+		h   *harness
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "gntagger_termui")
+		Expect(err).ToNot(HaveOccurred())
+		s = harnessSession(dir)
+		km, err := keymap.NewKeymap(nil)
+		Expect(err).ToNot(HaveOccurred())
+		h, err = newHarness(s, km, 100, 40)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("renders names, text and statistics", func() {
+		Expect(h.CurrentView()).To(Equal("names"))
+		Expect(h.View("names")).
+			To(ContainSubstring("Name: Phaeophleophleospora epicoccoides"))
+		Expect(h.View("names")).To(ContainSubstring("    1/7"))
+		Expect(h.View("text")).To(ContainSubstring("causes a leaf spot"))
+		Expect(h.View("stats")).To(ContainSubstring("Skip checked (F4) Y"))
+		Expect(h.View("stats")).To(ContainSubstring("Lang eng |"))
+		Expect(h.View("stats")).To(ContainSubstring("Acc.   0%"))
+		Expect(h.View("help")).To(HavePrefix("→ (yes*) next, ← back"))
+		Expect(h.clipboard).To(Equal("Phaeophleophleospora epicoccoides"))
+	})
+
+	It("accepts names moving forward and back", func() {
+		Expect(h.Press("Right", "Right", "Left")).To(Succeed())
+		Expect(s.Current()).To(Equal(1))
+		Expect(s.Names.Reviewed).To(Equal(map[int]bool{0: true, 1: true}))
+		ns := s.Names.Data.Names
+		Expect(ns[0].Annotation).To(Equal(annotation.Accepted.String()))
+		Expect(ns[1].Annotation).To(Equal(annotation.Accepted.String()))
+		Expect(ns[2].Annotation).To(Equal(annotation.NotAssigned.String()))
+		Expect(h.View("stats")).
+			To(ContainSubstring("[names] 1.00/1.00/1.00 occ. 1.00/1.00/1.00"))
+		Expect(h.View("stats")).To(ContainSubstring("Acc. 100%"))
+	})
+
+	It("switches statistics between name-strings and occurrences", func() {
+		Expect(h.Press("Right", "v")).To(Succeed())
+		Expect(h.View("stats")).To(ContainSubstring("names 1.00/1.00/1.00 [occ.]"))
+		Expect(h.Press("?")).To(Succeed())
+		Expect(h.View("overlay")).To(ContainSubstring("Stats        (v) occurrences"))
+		Expect(h.Press("Esc", "v")).To(Succeed())
+		Expect(h.View("stats")).To(ContainSubstring("[names]"))
+	})
+
+	It("annotates names and saves them", func() {
+		Expect(h.Press("Space", "Right", "s", "Right", "g", "Right",
+			"u", "Right", "y", "Ctrl-S")).To(Succeed())
+		Expect(h.View("names")).To(ContainSubstring("Annot: Accepted"))
+		names := NamesFromJSON(s.Names.Path)
+		var anns []string
+		for _, v := range names.Data.Names[:5] {
+			anns = append(anns, v.Annotation)
+		}
+		Expect(anns).To(Equal([]string{"NotName", "Species", "Genus",
+			"Uninomial", "Accepted"}))
+		Expect(h.View("stats")).To(ContainSubstring("Rej.  20%"))
+	})
+
+	It("skips names with decisions in express mode", func() {
+		for i := 1; i < 4; i++ {
+			s.Names.Data.Names[i].Annotation = annotation.NotName.String()
+		}
+		Expect(h.Press("Right")).To(Succeed())
+		Expect(s.Current()).To(Equal(4))
+		Expect(h.Press("Left", "Left", "F4", "Right")).To(Succeed())
+		Expect(s.Current()).To(Equal(3))
+		Expect(h.View("stats")).To(ContainSubstring("Skip checked (F4) N"))
+	})
+
+	It("switches propagation, order and unique mode", func() {
+		Expect(h.Press("p", "o", "m")).To(Succeed())
+		stats := h.View("stats")
+		Expect(stats).To(ContainSubstring("Propagate (p) name+type"))
+		Expect(stats).To(ContainSubstring("Order (o) informative"))
+		Expect(stats).To(ContainSubstring("Unique (m) Y"))
+		Expect(h.View("names")).To(ContainSubstring("(1 of 1, 0 exc.)"))
+	})
+
+	It("searches, jumps and filters with prompts", func() {
+		Expect(h.Press("/")).To(Succeed())
+		Expect(h.CurrentView()).To(Equal("prompt"))
+		Expect(h.Type("Corym")).To(Succeed())
+		Expect(h.Press("Enter")).To(Succeed())
+		Expect(h.CurrentView()).To(Equal("names"))
+		Expect(s.CurrentName().Name).To(Equal("Corymbia citriodora"))
+		Expect(h.View("help")).To(HavePrefix("/Corym/ 1 matches"))
+
+		Expect(h.Press(":")).To(Succeed())
+		Expect(h.Type("3")).To(Succeed())
+		Expect(h.Press("Enter")).To(Succeed())
+		Expect(s.Current()).To(Equal(2))
+
+		Expect(h.Press("f")).To(Succeed())
+		Expect(h.Type("type:Binomial")).To(Succeed())
+		Expect(h.Press("Enter", "Left")).To(Succeed())
+		Expect(s.Current()).To(Equal(2))
+		Expect(h.View("stats")).To(ContainSubstring("Filter (f) type:Binomial"))
+
+		Expect(h.Press(":")).To(Succeed())
+		Expect(h.Type("1")).To(Succeed())
+		Expect(h.Press("Esc")).To(Succeed())
+		Expect(s.Current()).To(Equal(2))
+	})
+
+	It("shows likelihoods and help", func() {
+		Expect(h.Press("l")).To(Succeed())
+		Expect(h.View("details")).To(ContainSubstring("Score"))
+		Expect(h.Press("l")).To(Succeed())
+		Expect(h.View("details")).To(Equal(""))
+
+		Expect(h.Press("?")).To(Succeed())
+		Expect(h.CurrentView()).To(Equal("overlay"))
+		Expect(h.View("overlay")).To(ContainSubstring("Annotations"))
+		Expect(h.Press("Right")).To(Succeed())
+		Expect(s.Current()).To(Equal(0))
+		Expect(h.Press("Esc")).To(Succeed())
+		Expect(h.CurrentView()).To(Equal("names"))
+	})
+
+	It("labels modes with remapped keys", func() {
+		km, err := keymap.NewKeymap(map[string][]string{
+			"propagation": {"P"}, "order": {"O"}, "unique": {"M"},
+			"express": {"F5"}, "filter": {"F"}, "likelihoods": {"d"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		h, err = newHarness(s, km, 100, 40)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(h.Press("F")).To(Succeed())
+		Expect(h.Type("type:Binomial")).To(Succeed())
+		Expect(h.Press("Enter", "d")).To(Succeed())
+		stats := h.View("stats")
+		for _, v := range []string{"Filter (F) type:Binomial",
+			"Skip checked (F5)", "Propagate (P)", "Order (O)", "Unique (M)"} {
+			Expect(stats).To(ContainSubstring(v))
+		}
+		Expect(h.Title("details")).To(Equal("Likelihoods (d)"))
+	})
+
+	It("runs commands of the palette", func() {
+		Expect(h.Press("Ctrl-P")).To(Succeed())
+		Expect(h.Type("exp")).To(Succeed())
+		Expect(h.Press("Enter")).To(Succeed())
+		Expect(h.View("help")).To(HavePrefix("Ambiguous command 'exp'"))
+		Expect(h.Press("Ctrl-P")).To(Succeed())
+		Expect(h.Type("export")).To(Succeed())
+		Expect(h.Press("Enter")).To(Succeed())
+		_, err := os.Stat(filepath.Join(s.Text.Path, ExportFile))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("scrolls the text without changing the current name", func() {
+		text := h.View("text")
+		Expect(h.Press("]", "]")).To(Succeed())
+		Expect(h.View("text")).ToNot(Equal(text))
+		Expect(h.Press("[", "[")).To(Succeed())
+		Expect(h.View("text")).To(Equal(text))
+		Expect(h.Press("PgDn")).To(Succeed())
+		Expect(h.View("text")).ToNot(ContainSubstring("leaf spot"))
+		Expect(s.Current()).To(Equal(0))
+		Expect(h.Press("c")).To(Succeed())
+		Expect(h.View("text")).To(Equal(text))
+	})
+
+	It("selects names with the mouse", func() {
+		lines := strings.Split(h.View("names"), "\n")
+		for i, l := range lines {
+			if strings.HasPrefix(l, "Name: Mycosphaerella") {
+				// the names view starts at the line 3 and has a frame
+				Expect(h.Click(5, i+4)).To(Succeed())
+			}
+		}
+		Expect(s.Current()).To(Equal(2))
+	})
+
+	It("saves names and quits", func() {
+		Expect(h.Press("Right", "Ctrl-C")).To(Equal(gocui.ErrQuit))
+		names := NamesFromJSON(s.Names.Path)
+		Expect(names.Data.Names[0].Annotation).To(Equal("Accepted"))
+		Expect(names.Data.Meta.CurrentName).To(Equal(1))
+	})
+})
+
+// harnessTemplate keeps files of the short text processed for the
+// harness, so the name-finder runs only once.
+var harnessTemplate string
+
+// harnessSession creates a session for the short text in a directory.
+func harnessSession(dir string) *Session {
+	gnt := NewGnTagger()
+	if harnessTemplate == "" {
+		var err error
+		harnessTemplate, err = ioutil.TempDir("", "gntagger_template")
+		Expect(err).ToNot(HaveOccurred())
+		path := filepath.Join(harnessTemplate, "short.txt")
+		PrepareFilesAndText(NewText(dataShort, path, "abcd"), 100-36, gnt)
+	}
+	path := filepath.Join(dir, "short.txt")
+	t := NewText(dataShort, path, "abcd")
+	Expect(os.Mkdir(t.Path, 0755)).To(Succeed())
+	for ft, f := range t.Files {
+		b, err := ioutil.ReadFile(filepath.Join(harnessTemplate,
+			"short.txt_gntagger", f))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(t.FilePath(ft), b, 0644)).To(Succeed())
+	}
+	names := PrepareFilesAndText(t, 100-36, gnt)
+	return NewSession(t, names, gnt)
+}
@@ -5,7 +5,9 @@ import (
 	"crypto/sha1"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"os"
 	"path/filepath"
 	"unicode"
 
@@ -35,6 +37,11 @@ type TextMeta struct {
 	GNtaggerVersion string `json:"gntagger_version"`
 	// Timestamp of the last save.
 	Timestamp string `json:"save_timestamp"`
+	// Settings are options used for finding and curating names. They are
+	// missing in sessions created by older versions of gntagger.
+	Settings *Settings `json:"settings,omitempty"`
+	// Language is the code of the language of the text.
+	Language string `json:"language,omitempty"`
 }
 
 // ToJSON converts meta-information into JSON format
@@ -46,6 +53,17 @@ func (t *TextMeta) ToJSON() []byte {
 	return json
 }
 
+// SaveMeta writes meta-information of the text to the MetaFile.
+func (t *Text) SaveMeta() error {
+	return ioutil.WriteFile(t.FilePath(MetaFile), t.TextMeta.ToJSON(), 0644)
+}
+
+// SetSettings records current settings of the GnTagger in meta-information.
+func (t *Text) SetSettings(gnt *GnTagger) {
+	s := gnt.Settings()
+	t.Settings = &s
+}
+
 // Text contains text of the input and its metadata
 type Text struct {
 	// Raw text, as it was given by a user
@@ -80,15 +98,46 @@ func NewText(data []byte, path string, GNtaggerVersion string) *Text {
 		Raw:      data,
 		Path:     path,
 		TextMeta: meta,
-		Files: map[FileType]string{
-			InputFile: "input.txt",
-			NamesFile: "names.json",
-			MetaFile:  "meta.json",
-		},
+		Files:    files(),
 	}
 	return text
 }
 
+// OpenSession loads the processed text and names of an existing curation
+// session. The path is either a directory created by gntagger, or the input
+// file next to such directory.
+func OpenSession(path string) (*Text, *Names, error) {
+	if stat, err := os.Stat(path); err == nil && !stat.IsDir() {
+		path = preparePath(path)
+	}
+	t := &Text{Path: path, Files: files()}
+	for ft, f := range t.Files {
+		if _, err := os.Stat(t.FilePath(ft)); err != nil {
+			return nil, nil,
+				fmt.Errorf("%s is not a gntagger session: no %s", path, f)
+		}
+	}
+	meta, err := ioutil.ReadFile(t.FilePath(MetaFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = jsoniter.Unmarshal(meta, &t.TextMeta); err != nil {
+		return nil, nil, err
+	}
+	processedTextFromFile(t)
+	names := NamesFromJSON(t.FilePath(NamesFile))
+	names.Pages = t.PageStarts()
+	return t, names, nil
+}
+
+func files() map[FileType]string {
+	return map[FileType]string{
+		InputFile: "input.txt",
+		NamesFile: "names.json",
+		MetaFile:  "meta.json",
+	}
+}
+
 // Errors returns list of errors that happened during execution of the
 // gntagger.
 func (t *Text) Errors() []error {
@@ -134,13 +183,32 @@ func PrepareFilesAndText(t *Text, w int, gnt *GnTagger) *Names {
 		log.Panic(err)
 	}
 
+	var names *Names
 	if exist {
+		if old := previousDataChecksums(t); old.Settings != nil {
+			gnt.UseSettings(*old.Settings)
+		}
 		processedTextFromFile(t)
-		return NamesFromJSON(t.FilePath(NamesFile))
+		names = NamesFromJSON(t.FilePath(NamesFile))
+		names.Pages = t.PageStarts()
+		t.SetSettings(gnt)
+		t.Language = names.Language()
+		if err = t.SaveMeta(); err != nil {
+			log.Panic(err)
+		}
+		if gnt.History != nil {
+			names.Past = gnt.History.Past(t.Checksum)
+		}
+	} else {
+		t.Process(w)
+		t.SetSettings(gnt)
+		names = NewNames(t, gnt)
+		t.Language = names.Language()
+		createFilesGently(t, names)
+	}
+	if gnt.RefDict != nil {
+		names.Verify(gnt.RefDict)
 	}
-	t.Process(w)
-	names := NewNames(t, gnt)
-	createFilesGently(t, names)
 	return names
 }
 
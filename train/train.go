@@ -0,0 +1,299 @@
+// Package train converts curated gntagger sessions into training data for
+// the Bayes name-finding algorithm of gnfinder, and measures how a retrained
+// model performs on curated texts.
+package train
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gnames/bayes"
+	"github.com/gnames/gnfinder"
+	"github.com/gnames/gnfinder/dict"
+	"github.com/gnames/gnfinder/lang"
+	"github.com/gnames/gnfinder/nlp"
+	"github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/annotation"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Curated is a part of a text that went through curation, together with
+// positions of names confirmed by a curator.
+type Curated struct {
+	// Path to the session directory.
+	Path string
+	// Language of the text.
+	Language lang.Language
+	// Text is the curated part of the processed text.
+	Text []rune
+	// Names are confirmed names with their offsets in Text.
+	Names nlp.NamesPositions
+	// Reviewed is the number of names reviewed by the curator or passed in
+	// express mode.
+	Reviewed int
+	// Dropped is the number of reviewed names that follow the first
+	// unreviewed name, and are left out of the curated text.
+	Dropped int
+}
+
+// NewCurated collects names confirmed by a curator. Only the part of the text
+// that ends before the first name the curator did not review or pass in
+// express mode is used, because decisions about the rest of the text are not
+// made yet. Names
+// marked as Uninomial, Genus or Species are cut to the corresponding number
+// of words.
+func NewCurated(t *gntagger.Text, n *gntagger.Names) *Curated {
+	l, err := lang.NewLanguage(n.Data.Meta.Language)
+	if err != nil {
+		l = lang.English
+	}
+	c := &Curated{Path: t.Path, Language: l, Text: t.Processed}
+	for i := range n.Data.Names {
+		if n.Seen(i) {
+			c.Reviewed++
+		}
+	}
+
+	names := n.Data.Names
+	if len(names) == 0 {
+		return c
+	}
+	for i := range names {
+		if !n.Seen(i) {
+			c.Text = t.Processed[:names[i].OffsetStart]
+			names = names[:i]
+			c.Dropped = c.Reviewed - i
+			break
+		}
+	}
+
+	for _, v := range names {
+		ann, err := annotation.NewAnnotation(v.Annotation)
+		if err != nil {
+			continue
+		}
+		var words int
+		switch ann {
+		case annotation.Accepted, annotation.NotAssigned:
+			words = -1
+		case annotation.Uninomial, annotation.Genus:
+			words = 1
+		case annotation.Species:
+			words = 2
+		default:
+			continue
+		}
+		end := wordsEnd(t.Processed, v.OffsetStart, v.OffsetEnd, words)
+		name := strings.Join(strings.Fields(string(t.Processed[v.OffsetStart:end])), " ")
+		c.Names = append(c.Names,
+			nlp.NameData{Name: name, Start: v.OffsetStart, End: end})
+	}
+	return c
+}
+
+// wordsEnd returns the end offset of the first words in a text between start
+// and end. If words is negative the end stays the same.
+func wordsEnd(text []rune, start int, end int, words int) int {
+	if words < 0 {
+		return end
+	}
+	inWord := false
+	for i := start; i < end; i++ {
+		space := text[i] == ' ' || text[i] == '\n' || text[i] == '\t'
+		if space && inWord {
+			words--
+			if words == 0 {
+				return i
+			}
+		}
+		inWord = !space
+	}
+	return end
+}
+
+// Train creates Bayes weights for every language of curated texts. Counts
+// collected from curated texts are added to the default weights of
+// gnfinder.
+func Train(cs []*Curated,
+	d *dict.Dictionary) map[lang.Language]*bayes.NaiveBayes {
+	res := nlp.BayesWeights()
+	for l, td := range trainingData(cs) {
+		nb := Merge(res[l], nlp.Train(td, d))
+		res[l] = nb
+		if l == lang.English {
+			res[lang.DefaultLanguage] = nb
+		}
+	}
+	return res
+}
+
+func trainingData(cs []*Curated) map[lang.Language]nlp.TrainingData {
+	res := make(map[lang.Language]nlp.TrainingData)
+	for _, c := range cs {
+		td, ok := res[c.Language]
+		if !ok {
+			td = make(nlp.TrainingData)
+			res[c.Language] = td
+		}
+		td[nlp.FileName(c.Path)] = &nlp.TextData{
+			Text:           c.Text,
+			NamesPositions: c.Names,
+		}
+	}
+	return res
+}
+
+// Merge sums frequencies collected by two Bayes classifiers.
+func Merge(a, b *bayes.NaiveBayes) *bayes.NaiveBayes {
+	res := bayes.NewNaiveBayes()
+	for _, nb := range []*bayes.NaiveBayes{a, b} {
+		for l, v := range nb.LabelFreq {
+			if _, ok := res.LabelFreq[l]; !ok {
+				res.Labels = append(res.Labels, l)
+			}
+			res.LabelFreq[l] += v
+			res.Total += v
+		}
+		for name, values := range nb.FeatureFreq {
+			if _, ok := res.FeatureFreq[name]; !ok {
+				res.FeatureFreq[name] = make(map[bayes.FeatureValue]map[bayes.Labeler]float64)
+				res.FeatureTotal[name] = make(map[bayes.FeatureValue]float64)
+			}
+			for value, labels := range values {
+				if _, ok := res.FeatureFreq[name][value]; !ok {
+					res.FeatureFreq[name][value] = make(map[bayes.Labeler]float64)
+				}
+				for l, v := range labels {
+					res.FeatureFreq[name][value][l] += v
+					res.FeatureTotal[name][value] += v
+				}
+			}
+		}
+	}
+	return res
+}
+
+// Write saves training data in the format of gnfinder's 'data/training'
+// directory, and the new weights in the format of gnfinder's
+// 'data/files/nlp' directory. Curated texts have no parts without names, so
+// no_names files are empty. They are still written, because gnfinder cannot
+// load training data of a language without them.
+func Write(dir string, cs []*Curated,
+	weights map[lang.Language]*bayes.NaiveBayes) error {
+	for l, td := range trainingData(cs) {
+		ldir := filepath.Join(dir, l.String())
+		if err := os.MkdirAll(ldir, 0755); err != nil {
+			return err
+		}
+		var text []rune
+		var nps nlp.NamesPositions
+		var paths []string
+		for k := range td {
+			paths = append(paths, string(k))
+		}
+		sort.Strings(paths)
+		for _, p := range paths {
+			v := td[nlp.FileName(p)]
+			shift := len(text)
+			for _, n := range v.NamesPositions {
+				nps = append(nps, nlp.NameData{Name: n.Name,
+					Start: n.Start + shift, End: n.End + shift})
+			}
+			text = append(text, v.Text...)
+			text = append(text, '\n', '\n')
+		}
+		if err := writeText(ldir, "names", text, nps); err != nil {
+			return err
+		}
+		if err := writeText(ldir, "no_names", nil, nlp.NamesPositions{}); err != nil {
+			return err
+		}
+		err := ioutil.WriteFile(filepath.Join(ldir, "bayes.json"),
+			weights[l].Dump(), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeText(dir string, file string, text []rune,
+	nps nlp.NamesPositions) error {
+	err := ioutil.WriteFile(filepath.Join(dir, file+".txt"),
+		[]byte(string(text)), 0644)
+	if err != nil {
+		return err
+	}
+	json, err := jsoniter.MarshalIndent(nps, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, file+".json"), json, 0644)
+}
+
+// Quality describes how well found names match curated ones.
+type Quality struct {
+	TruePos   int
+	FalsePos  int
+	FalseNeg  int
+	Precision float64
+	Recall    float64
+	F1        float64
+}
+
+// NewQuality calculates precision, recall and F1 from counts.
+func NewQuality(tp, fp, fn int) Quality {
+	q := Quality{TruePos: tp, FalsePos: fp, FalseNeg: fn}
+	if tp+fp > 0 {
+		q.Precision = float64(tp) / float64(tp+fp)
+	}
+	if tp+fn > 0 {
+		q.Recall = float64(tp) / float64(tp+fn)
+	}
+	if q.Precision+q.Recall > 0 {
+		q.F1 = 2 * q.Precision * q.Recall / (q.Precision + q.Recall)
+	}
+	return q
+}
+
+func (q Quality) String() string {
+	return fmt.Sprintf("precision %.3f, recall %.3f, F1 %.3f "+
+		"(tp %d, fp %d, fn %d)",
+		q.Precision, q.Recall, q.F1, q.TruePos, q.FalsePos, q.FalseNeg)
+}
+
+// Evaluate finds names in a curated text using given weights, and compares
+// them with the curated names. Names with odds below oddsHigh are not
+// counted as found.
+func Evaluate(c *Curated, weights map[lang.Language]*bayes.NaiveBayes,
+	d *dict.Dictionary, oddsHigh float64) Quality {
+	gnf := gnfinder.NewGNfinder(
+		gnfinder.OptDict(d),
+		gnfinder.OptBayesWeights(weights),
+		gnfinder.OptLanguage(c.Language),
+		gnfinder.OptBayesThreshold(oddsHigh),
+	)
+	out := gnf.FindNames([]byte(string(c.Text)))
+
+	type span struct{ start, end int }
+	gold := make(map[span]struct{})
+	for _, v := range c.Names {
+		gold[span{v.Start, v.End}] = struct{}{}
+	}
+	var tp, fp int
+	for _, v := range out.Names {
+		if v.Odds != 0 && v.Odds < oddsHigh {
+			continue
+		}
+		if _, ok := gold[span{v.OffsetStart, v.OffsetEnd}]; ok {
+			tp++
+		} else {
+			fp++
+		}
+	}
+	return NewQuality(tp, fp, len(gold)-tp)
+}
@@ -0,0 +1,121 @@
+package gntagger_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gnames/bayes"
+	"github.com/gnames/gnfinder/dict"
+	"github.com/gnames/gnfinder/lang"
+	"github.com/gnames/gnfinder/nlp"
+	"github.com/gnames/gnfinder/output"
+	"github.com/gnames/gntagger"
+	"github.com/gnames/gntagger/annotation"
+	. "github.com/gnames/gntagger/train"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Train", func() {
+	Describe("NewCurated", func() {
+		It("collects names confirmed before the first unreviewed name", func() {
+			t, n := curatedSession()
+			c := NewCurated(t, n)
+			Expect(c.Language).To(Equal(lang.English))
+			Expect(string(c.Text)).To(Equal("Venus mercenaria Lin. lives with " +
+				"Octopus vulgaris near\nVenus. Then "))
+			Expect(c.Names).To(Equal(nlp.NamesPositions{
+				{Name: "Venus mercenaria", Start: 0, End: 16},
+				{Name: "Octopus", Start: 33, End: 40},
+			}))
+		})
+
+		It("uses the whole text when all names are reviewed or passed", func() {
+			t, n := curatedSession()
+			n.Data.Names[3].Annotation = annotation.Accepted.String()
+			n.Passed = map[int]bool{3: true}
+			c := NewCurated(t, n)
+			Expect(c.Text).To(Equal(t.Processed))
+			Expect(c.Names).To(HaveLen(3))
+			Expect(c.Dropped).To(Equal(0))
+		})
+
+		It("stops at a name the curator jumped over", func() {
+			t, n := curatedSession()
+			delete(n.Reviewed, 1)
+			c := NewCurated(t, n)
+			Expect(string(c.Text)).To(Equal("Venus mercenaria Lin. lives with "))
+			Expect(c.Names).To(Equal(nlp.NamesPositions{
+				{Name: "Venus mercenaria", Start: 0, End: 16},
+			}))
+			Expect(c.Reviewed).To(Equal(2))
+			Expect(c.Dropped).To(Equal(1))
+		})
+	})
+
+	Describe("Merge", func() {
+		It("sums frequencies of two classifiers", func() {
+			w := nlp.BayesWeights()[lang.English]
+			nb := Merge(w, w)
+			Expect(nb.Total).To(Equal(2 * w.Total))
+			Expect(nb.Labels).To(HaveLen(2))
+			f := bayes.FeatureName("abbr")
+			v := bayes.FeatureValue("true")
+			Expect(nb.FeatureTotal[f][v]).To(Equal(2 * w.FeatureTotal[f][v]))
+		})
+	})
+
+	Describe("Write", func() {
+		It("saves training data that gnfinder can load", func() {
+			dir, err := ioutil.TempDir("", "gntagger_train")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+			t, n := curatedSession()
+			cs := []*Curated{NewCurated(t, n)}
+			d := dict.LoadDictionary()
+			Expect(Write(dir, cs, Train(cs, d))).To(Succeed())
+
+			td := nlp.NewTrainingData(filepath.Join(dir, "eng"))
+			Expect(td).To(HaveLen(2))
+			noNames := td[nlp.FileName("no_names")]
+			Expect(noNames.Text).To(BeEmpty())
+			Expect(noNames.NamesPositions).To(BeEmpty())
+			Expect(td[nlp.FileName("names")].NamesPositions).
+				To(Equal(cs[0].Names))
+			Expect(nlp.Train(td, d).Total).To(BeNumerically(">", 0))
+		})
+	})
+
+	Describe("NewQuality", func() {
+		It("calculates precision, recall and F1", func() {
+			q := NewQuality(3, 1, 3)
+			Expect(q.Precision).To(Equal(0.75))
+			Expect(q.Recall).To(Equal(0.5))
+			Expect(q.F1).To(BeNumerically("~", 0.6, 0.0001))
+			Expect(NewQuality(0, 0, 0).F1).To(Equal(0.0))
+		})
+	})
+})
+
+func curatedSession() (*gntagger.Text, *gntagger.Names) {
+	txt := "Venus mercenaria Lin. lives with Octopus vulgaris near\n" +
+		"Venus. Then Mollusca."
+	t := gntagger.NewText([]byte(txt), "", "abcd")
+	t.Processed = []rune(txt)
+	n := &gntagger.Names{}
+	n.Data.Meta.Language = "eng"
+	n.Data.Names = []output.Name{
+		{Name: "Venus mercenaria", OffsetStart: 0, OffsetEnd: 16,
+			Annotation: annotation.Accepted.String()},
+		{Name: "Octopus vulgaris", OffsetStart: 33, OffsetEnd: 49,
+			Annotation: annotation.Genus.String()},
+		{Name: "Venus", OffsetStart: 55, OffsetEnd: 60,
+			Annotation: annotation.NotName.String()},
+		{Name: "Mollusca", OffsetStart: 67, OffsetEnd: 75},
+	}
+	n.Data.Meta.CurrentName = 3
+	n.Reviewed = map[int]bool{0: true, 1: true, 2: true}
+	return t, n
+}
@@ -0,0 +1,124 @@
+package gntagger
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gnames/gntagger/annotation"
+)
+
+// Unique is a distinct name-string with all its occurrences in the text.
+type Unique struct {
+	// Name is the name-string.
+	Name string
+	// Indices are positions of occurrences of the name in Names.Data.Names.
+	Indices []int
+}
+
+// Uniques returns distinct name-strings in the order of their first
+// occurrence in the text.
+func (n *Names) Uniques() []Unique {
+	var res []Unique
+	pos := make(map[string]int)
+	for i, v := range n.Data.Names {
+		j, ok := pos[v.Name]
+		if !ok {
+			j = len(res)
+			pos[v.Name] = j
+			res = append(res, Unique{Name: v.Name})
+		}
+		res[j].Indices = append(res[j].Indices, i)
+	}
+	return res
+}
+
+// Annotations counts annotations of all occurrences of a unique name.
+func (n *Names) Annotations(u *Unique) map[annotation.Annotation]int {
+	res := make(map[annotation.Annotation]int)
+	for _, i := range u.Indices {
+		ann, err := annotation.NewAnnotation(n.Data.Names[i].Annotation)
+		if err != nil {
+			ann = annotation.NotAssigned
+		}
+		res[ann]++
+	}
+	return res
+}
+
+// IsReviewed returns true if all occurrences of a unique name have a decision.
+func (n *Names) IsReviewed(u *Unique) bool {
+	for ann := range n.Annotations(u) {
+		if ann.In(annotation.NotAssigned, annotation.Doubtful) {
+			return false
+		}
+	}
+	return true
+}
+
+// AnnotateAll applies an annotation to all occurrences of a unique name.
+// Occurrences marked as exceptions receive the opposite decision: Accepted
+// if the annotation is NotName, and NotName otherwise.
+func (n *Names) AnnotateAll(u *Unique, a annotation.Annotation,
+	exceptions map[int]bool) {
+	opposite := annotation.NotName
+	if a == annotation.NotName {
+		opposite = annotation.Accepted
+	}
+	for _, i := range u.Indices {
+		n.decide(i)
+		n.change(i)
+		if exceptions[i] {
+			n.Data.Names[i].Annotation = opposite.String()
+		} else {
+			n.Data.Names[i].Annotation = a.String()
+		}
+	}
+}
+
+// UniqueStrings composes text to show a unique name with the index i in
+// terminal gui. The occurrence is the index of the occurrence shown in the
+// text, exceptions are occurrences that will get the opposite decision.
+func (n *Names) UniqueStrings(u *Unique, i int, total int, current bool,
+	occurrence int, exceptions map[int]bool) []string {
+	res := make([]string, 4)
+	first := &n.Data.Names[u.Indices[0]]
+	nameString := u.Name
+	if current {
+		nameString = fmt.Sprintf("\033[33;40;1m%s\033[0m", nameString)
+		mark := ""
+		if exceptions[u.Indices[occurrence]] {
+			mark = "*"
+		}
+		res[0] = fmt.Sprintf("    %d/%d (%d%s of %d, %d exc.)", i+1, total,
+			occurrence+1, mark, len(u.Indices), len(exceptions))
+	} else {
+		res[0] = fmt.Sprintf("    %d/%d (x%d)", i+1, total, len(u.Indices))
+	}
+	res[1] = first.Type
+	if first.Odds != 0.0 {
+		res[1] = fmt.Sprintf("%s (Score: %0.2f)", res[1], math.Log10(first.Odds))
+	}
+	res[2] = fmt.Sprintf("Name: %s", nameString)
+
+	anns := n.Annotations(u)
+	if len(anns) == 1 {
+		for ann := range anns {
+			res[3] = ann.Format()
+		}
+		return res
+	}
+	counts := make([]string, 0, len(anns))
+	for ann, c := range anns {
+		label := ann.String()
+		if ann == annotation.NotAssigned {
+			label = "new"
+		}
+		counts = append(counts, fmt.Sprintf("\033[%d;40;2m%d %s\033[0m",
+			ann.Color(), c, label))
+	}
+	sort.Strings(counts)
+	res[3] = "Annot: " + strings.Join(counts, ", ")
+	return res
+}